@@ -2,12 +2,15 @@ package config
 
 import (
 	"errors"
-	"flag"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/ilyakaznacheev/cleanenv"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,23 +18,178 @@ type (
 	Config struct {
 		LoggerConfig LoggerConfig `yaml:"logger"`
 		ServerConfig ServerConfig `yaml:"server"`
+
+		RateLimitConfig RateLimitConfig `yaml:"rate_limit"`
+
+		FaultConfig FaultConfig `yaml:"fault_injection"`
+
+		StaticConfig StaticConfig `yaml:"static"`
+
+		CompressionConfig CompressionConfig `yaml:"compression"`
+		CORSConfig        CORSConfig        `yaml:"cors"`
+
+		AuthConfig AuthConfig `yaml:"auth"`
+
+		sources     map[string]string
+		printConfig bool
 	}
 
 	LoggerConfig struct {
-		Format LogFormat `yaml:"format" env:"LOG_FORMAT" env-description:"log format: text,json"`
-		Level  LogLevel  `yaml:"level"  env:"LOG_LEVEL"  env-description:"log level: debug,info,warn,error"`
+		Format LogFormat `yaml:"format" env:"LOG_FORMAT" env-default:"text" env-description:"log format: text,json"`
+		Level  LogLevel  `yaml:"level"  env:"LOG_LEVEL"  env-default:"info" env-description:"log level: debug,info,warn,error"`
+
+		Outputs   []LogOutput       `yaml:"outputs"    env:"LOG_OUTPUTS"    env-default:"stdout"           env-description:"log sinks: stdout,stderr,file"`
+		File      LogFileConfig     `yaml:"file"`
+		Sampling  LogSamplingConfig `yaml:"sampling"`
+		AdminPath string            `yaml:"admin_path" env:"LOG_ADMIN_PATH" env-description:"runtime log level endpoint, requires auth (empty - disabled)"`
+	}
+
+	LogFileConfig struct {
+		Path        string        `yaml:"path"         env:"LOG_FILE_PATH"         env-description:"log file path"`
+		MaxSize     int           `yaml:"max_size"     env:"LOG_FILE_MAX_SIZE"     env-default:"100" env-description:"rotate after megabytes (0 - never)"`
+		RotateEvery time.Duration `yaml:"rotate_every" env:"LOG_FILE_ROTATE_EVERY" env-default:"24h" env-description:"rotate after duration (0 - never)"`
+		MaxBackups  int           `yaml:"max_backups"  env:"LOG_FILE_MAX_BACKUPS"  env-default:"7"   env-description:"rotated files to keep (0 - all)"`
+		MaxAge      time.Duration `yaml:"max_age"      env:"LOG_FILE_MAX_AGE"      env-default:"0s"  env-description:"remove rotated files older than (0 - never)"`
+	}
+
+	LogSamplingConfig struct {
+		Enabled    bool          `yaml:"enabled"    env:"LOG_SAMPLING_ENABLED"    env-default:"false" env-description:"sample debug and info logs"`
+		Interval   time.Duration `yaml:"interval"   env:"LOG_SAMPLING_INTERVAL"   env-default:"1s"    env-description:"sampling window"`
+		First      int           `yaml:"first"      env:"LOG_SAMPLING_FIRST"      env-default:"10"    env-description:"messages logged per window before sampling"`
+		Thereafter int           `yaml:"thereafter" env:"LOG_SAMPLING_THEREAFTER" env-default:"100"   env-description:"then log every n-th message"`
 	}
 
 	LogLevel  string
 	LogFormat string
+	LogOutput string
 
 	ServerConfig struct {
-		Host ServerHost `yaml:"host" env:"HOST" env-description:"server host (0.0.0.0)"`
-		Port ServerPort `yaml:"port" env:"PORT" env-description:"server port (>= 1024)"`
+		Host ServerHost `yaml:"host" env:"HOST" env-default:"0.0.0.0" env-description:"server host, ip or hostname (0.0.0.0)"`
+		Port ServerPort `yaml:"port" env:"PORT" env-default:"9090"    env-description:"server port (>= 1024)"`
+
+		Listeners []ListenerConfig `yaml:"listeners" env:"LISTENERS" env-description:"listeners (yaml/json list), host and port are used if empty"`
+
+		ReadTimeout     time.Duration `yaml:"read_timeout"     env:"READ_TIMEOUT"     env-default:"5s"  env-description:"request read timeout"`
+		WriteTimeout    time.Duration `yaml:"write_timeout"    env:"WRITE_TIMEOUT"    env-default:"3s"  env-description:"response write timeout"`
+		DrainDelay      time.Duration `yaml:"drain_delay"      env:"DRAIN_DELAY"      env-default:"0s"  env-description:"delay between readiness flip and draining"`
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"10s" env-description:"in-flight requests drain timeout"`
+
+		TLS TLSConfig `yaml:"tls"`
 	}
 
 	ServerPort int
 	ServerHost string
+
+	ListenerConfig struct {
+		Network  ListenerNetwork `yaml:"network"  json:"network"`
+		Address  string          `yaml:"address"  json:"address"`
+		Mode     string          `yaml:"mode"     json:"mode,omitempty"`
+		Redirect bool            `yaml:"redirect" json:"redirect,omitempty"`
+	}
+
+	ListenerNetwork string
+
+	TLSConfig struct {
+		Enabled        bool          `yaml:"enabled"         env:"TLS_ENABLED"         env-default:"false" env-description:"serve https"`
+		CertFile       string        `yaml:"cert_file"       env:"TLS_CERT_FILE"       env-description:"certificate file (pem)"`
+		KeyFile        string        `yaml:"key_file"        env:"TLS_KEY_FILE"        env-description:"private key file (pem)"`
+		SelfSigned     bool          `yaml:"self_signed"     env:"TLS_SELF_SIGNED"     env-default:"false" env-description:"generate self-signed certificate when no files are set (dev only)"`
+		MinVersion     TLSVersion    `yaml:"min_version"     env:"TLS_MIN_VERSION"     env-default:"1.2"   env-description:"minimum tls version: 1.2,1.3"`
+		ClientAuth     ClientAuth    `yaml:"client_auth"     env:"TLS_CLIENT_AUTH"     env-default:"none"  env-description:"client certificates: none,request,require"`
+		ClientCAFile   string        `yaml:"client_ca_file"  env:"TLS_CLIENT_CA_FILE"  env-description:"client ca bundle (pem) for mTLS"`
+		HTTP2          bool          `yaml:"http2"           env:"TLS_HTTP2"           env-default:"true"  env-description:"enable http/2"`
+		RedirectPort   int           `yaml:"redirect_port"   env:"TLS_REDIRECT_PORT"   env-default:"0"     env-description:"plain http port on host redirecting to https (0 - disabled), redirect listeners replace it"`
+		ReloadInterval time.Duration `yaml:"reload_interval" env:"TLS_RELOAD_INTERVAL" env-default:"1m"    env-description:"certificate files check interval"`
+	}
+
+	TLSVersion string
+	ClientAuth string
+
+	RateLimitConfig struct {
+		Enabled     bool          `yaml:"enabled"       env:"RATE_LIMIT_ENABLED"       env-default:"false" env-description:"enable per-client rate limiting"`
+		Rate        float64       `yaml:"rate"          env:"RATE_LIMIT_RATE"          env-default:"10"    env-description:"tokens refilled per second"`
+		Burst       int           `yaml:"burst"         env:"RATE_LIMIT_BURST"         env-default:"20"    env-description:"bucket size"`
+		Key         RateLimitKey  `yaml:"key"           env:"RATE_LIMIT_KEY"           env-default:"ip"    env-description:"bucket key: ip,header,route"`
+		Header      string        `yaml:"header"        env:"RATE_LIMIT_HEADER"        env-description:"header name for the header key, client ip if missing"`
+		IdleTimeout time.Duration `yaml:"idle_timeout"  env:"RATE_LIMIT_IDLE_TIMEOUT"  env-default:"10m"   env-description:"drop buckets unused for this long"`
+		MaxInFlight int           `yaml:"max_in_flight" env:"RATE_LIMIT_MAX_IN_FLIGHT" env-default:"0"     env-description:"global concurrent requests limit (0 - unlimited)"`
+	}
+
+	RateLimitKey string
+
+	FaultConfig struct {
+		Enabled   bool        `yaml:"enabled"    env:"FAULT_ENABLED"    env-default:"false"         env-description:"enable fault injection (never in production)"`
+		AdminPath string      `yaml:"admin_path" env:"FAULT_ADMIN_PATH" env-description:"fault rules admin endpoint, requires auth (empty - disabled)"`
+		Rules     []FaultRule `yaml:"rules"      env:"FAULT_RULES"      env-description:"fault rules (yaml/json list)"`
+	}
+
+	StaticConfig struct {
+		Enabled bool          `yaml:"enabled" env:"STATIC_ENABLED" env-default:"false" env-description:"serve static files"`
+		Dir     string        `yaml:"dir"     env:"STATIC_DIR"     env-description:"files directory (embedded files if empty)"`
+		Prefix  string        `yaml:"prefix"  env:"STATIC_PREFIX"  env-default:"/app/" env-description:"url prefix, must end with /"`
+		Index   string        `yaml:"index"   env:"STATIC_INDEX"   env-default:"index.html" env-description:"directory index file"`
+		Listing bool          `yaml:"listing" env:"STATIC_LISTING" env-default:"false" env-description:"list directories without index"`
+		SPA     bool          `yaml:"spa"     env:"STATIC_SPA"     env-default:"false" env-description:"serve index for unknown paths"`
+		MaxAge  time.Duration `yaml:"max_age" env:"STATIC_MAX_AGE" env-default:"1h"    env-description:"Cache-Control max-age for assets"`
+	}
+
+	CompressionConfig struct {
+		Enabled      bool     `yaml:"enabled"       env:"COMPRESSION_ENABLED"       env-default:"false" env-description:"compress responses"`
+		Level        int      `yaml:"level"         env:"COMPRESSION_LEVEL"         env-default:"5"     env-description:"compression level 1-9"`
+		MinSize      int      `yaml:"min_size"      env:"COMPRESSION_MIN_SIZE"      env-default:"1024"  env-description:"minimum response size to compress"`
+		Brotli       bool     `yaml:"brotli"        env:"COMPRESSION_BROTLI"        env-default:"false" env-description:"offer brotli encoding"`
+		ContentTypes []string `yaml:"content_types" env:"COMPRESSION_CONTENT_TYPES" env-default:"text/,application/json,application/javascript,application/xml,image/svg+xml" env-description:"compressible content type prefixes"` //nolint:lll
+	}
+
+	CORSConfig struct {
+		Enabled          bool          `yaml:"enabled"           env:"CORS_ENABLED"           env-default:"false" env-description:"enable cors"`
+		AllowedOrigins   []string      `yaml:"allowed_origins"   env:"CORS_ALLOWED_ORIGINS"   env-default:"*" env-description:"allowed origins, * or https://*.example.com patterns"`
+		AllowedMethods   []string      `yaml:"allowed_methods"   env:"CORS_ALLOWED_METHODS"   env-default:"GET,HEAD,POST,PUT,PATCH,DELETE" env-description:"allowed methods"`
+		AllowedHeaders   []string      `yaml:"allowed_headers"   env:"CORS_ALLOWED_HEADERS"   env-default:"Accept,Content-Type,Authorization,X-Request-Id" env-description:"allowed request headers, * for any"` //nolint:lll
+		ExposedHeaders   []string      `yaml:"exposed_headers"   env:"CORS_EXPOSED_HEADERS"   env-default:"X-Request-Id" env-description:"headers exposed to the browser"`
+		AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" env-default:"false" env-description:"allow cookies and auth headers"`
+		MaxAge           time.Duration `yaml:"max_age"           env:"CORS_MAX_AGE"           env-default:"10m" env-description:"preflight cache duration"`
+	}
+
+	AuthConfig struct {
+		Enabled      bool           `yaml:"enabled"        env:"AUTH_ENABLED"        env-default:"false"     env-description:"enable authentication"`
+		Realm        string         `yaml:"realm"          env:"AUTH_REALM"          env-default:"work3"     env-description:"authentication realm"`
+		APIKeyHeader string         `yaml:"api_key_header" env:"AUTH_API_KEY_HEADER" env-default:"X-Api-Key" env-description:"api key header"`
+		APIKeys      []APIKeyConfig `yaml:"api_keys"       env:"AUTH_API_KEYS"       env-description:"static api keys (yaml/json list)" secret:"true"`
+		HtpasswdFile string         `yaml:"htpasswd_file"  env:"AUTH_HTPASSWD_FILE"  env-description:"bcrypt htpasswd file for basic auth"`
+		BasicScopes  []string       `yaml:"basic_scopes"   env:"AUTH_BASIC_SCOPES"   env-description:"scopes granted to basic auth users"`
+		JWT          JWTConfig      `yaml:"jwt"`
+	}
+
+	APIKeyConfig struct {
+		Name   string   `yaml:"name"   json:"name"`
+		Key    string   `yaml:"key"    json:"key"`
+		Scopes []string `yaml:"scopes" json:"scopes"`
+	}
+
+	JWTConfig struct {
+		Secret     string        `yaml:"secret"      env:"AUTH_JWT_SECRET"      env-description:"HS256 shared secret" secret:"true"`
+		JWKSFile   string        `yaml:"jwks_file"   env:"AUTH_JWT_JWKS_FILE"   env-description:"RS256 public keys (jwks)"`
+		Issuer     string        `yaml:"issuer"      env:"AUTH_JWT_ISSUER"      env-description:"required iss claim"`
+		Audience   string        `yaml:"audience"    env:"AUTH_JWT_AUDIENCE"    env-description:"required aud claim"`
+		RequireExp bool          `yaml:"require_exp" env:"AUTH_JWT_REQUIRE_EXP" env-default:"true"  env-description:"reject tokens without exp claim"`
+		Leeway     time.Duration `yaml:"leeway"      env:"AUTH_JWT_LEEWAY"      env-default:"30s"   env-description:"clock skew allowance"`
+		ScopeClaim string        `yaml:"scope_claim" env:"AUTH_JWT_SCOPE_CLAIM" env-default:"scope" env-description:"claim with space separated scopes"`
+	}
+
+	FaultRule struct {
+		Name            string            `yaml:"name"              json:"name"`
+		Enabled         bool              `yaml:"enabled"           json:"enabled"`
+		Method          string            `yaml:"method"            json:"method,omitempty"`
+		Route           string            `yaml:"route"             json:"route,omitempty"`
+		Headers         map[string]string `yaml:"headers"           json:"headers,omitempty"`
+		Query           map[string]string `yaml:"query"             json:"query,omitempty"`
+		Probability     float64           `yaml:"probability"       json:"probability,omitempty"`
+		Delay           time.Duration     `yaml:"delay"             json:"delay,omitempty"`
+		Status          int               `yaml:"status"            json:"status,omitempty"`
+		StatusFromQuery string            `yaml:"status_from_query" json:"status_from_query,omitempty"`
+		Abort           bool              `yaml:"abort"             json:"abort,omitempty"`
+	}
 )
 
 const (
@@ -42,36 +200,56 @@ const (
 	LogLevelInfo  LogLevel = "info"
 	LogLevelWarn  LogLevel = "warn"
 	LogLevelError LogLevel = "error"
+
+	LogOutputStdout LogOutput = "stdout"
+	LogOutputStderr LogOutput = "stderr"
+	LogOutputFile   LogOutput = "file"
+
+	ListenerTCP     ListenerNetwork = "tcp"
+	ListenerTCP4    ListenerNetwork = "tcp4"
+	ListenerTCP6    ListenerNetwork = "tcp6"
+	ListenerUnix    ListenerNetwork = "unix"
+	ListenerSystemd ListenerNetwork = "systemd"
+
+	TLSVersion12 TLSVersion = "1.2"
+	TLSVersion13 TLSVersion = "1.3"
+
+	ClientAuthNone    ClientAuth = "none"
+	ClientAuthRequest ClientAuth = "request"
+	ClientAuthRequire ClientAuth = "require"
+
+	RateLimitKeyIP     RateLimitKey = "ip"
+	RateLimitKeyHeader RateLimitKey = "header"
+	RateLimitKeyRoute  RateLimitKey = "route"
 )
 
 var (
 	ErrInvalidLogLevel  = errors.New("invalid log level")
 	ErrInvalidLogFormat = errors.New("invalid log format")
+	ErrInvalidLogOutput = errors.New("invalid log output")
 
-	ErrInvalidServerPort = errors.New("invalid server port")
-	ErrInvalidServerHost = errors.New("invalid server host")
-)
+	ErrInvalidServerPort    = errors.New("invalid server port")
+	ErrInvalidServerHost    = errors.New("invalid server host")
+	ErrInvalidServerTimeout = errors.New("invalid server timeout")
+	ErrInvalidListener      = errors.New("invalid listener")
 
-func Load() (*Config, error) {
-	var config Config
+	ErrInvalidTLSConfig  = errors.New("invalid tls config")
+	ErrInvalidTLSVersion = errors.New("invalid tls version")
+	ErrInvalidClientAuth = errors.New("invalid tls client auth")
 
-	filepath := flag.String("c", "config.yml", "path to config file")
+	ErrInvalidRateLimit    = errors.New("invalid rate limit config")
+	ErrInvalidRateLimitKey = errors.New("invalid rate limit key")
 
-	flag.Usage = cleanenv.FUsage(flag.CommandLine.Output(), &config, nil, flag.Usage)
-	flag.Parse()
+	ErrInvalidFaultRule = errors.New("invalid fault rule")
 
-	err := cleanenv.ReadConfig(*filepath, &config)
-	if err != nil {
-		return nil, fmt.Errorf("invalid config: %w", err)
-	}
+	ErrInvalidStaticConfig = errors.New("invalid static config")
 
-	err = config.Validate()
-	if err != nil {
-		return nil, fmt.Errorf("invalid config: %w", err)
-	}
+	ErrInvalidCompressionConfig = errors.New("invalid compression config")
+	ErrInvalidCORSConfig        = errors.New("invalid cors config")
 
-	return &config, nil
-}
+	ErrInvalidAuthConfig = errors.New("invalid auth config")
+	ErrAdminAuthRequired = errors.New("admin endpoint requires auth")
+)
 
 func (c *Config) Validate() error {
 	if err := c.LoggerConfig.Validate(); err != nil {
@@ -82,11 +260,43 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.RateLimitConfig.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.FaultConfig.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.StaticConfig.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.CompressionConfig.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.CORSConfig.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.AuthConfig.Validate(); err != nil {
+		return err
+	}
+
+	if c.LoggerConfig.AdminPath != "" && !c.AuthConfig.Enabled {
+		return fmt.Errorf("%w: %s", ErrAdminAuthRequired, c.LoggerConfig.AdminPath)
+	}
+
+	if c.FaultConfig.Enabled && c.FaultConfig.AdminPath != "" && !c.AuthConfig.Enabled {
+		return fmt.Errorf("%w: %s", ErrAdminAuthRequired, c.FaultConfig.AdminPath)
+	}
+
 	return nil
 }
 
 func (c *Config) String() string {
-	return fmt.Sprintf("{Logger: %s, Server: %s}", &c.LoggerConfig, &c.ServerConfig)
+	return fmt.Sprintf("{Logger: %s, Server: %s, RateLimit: %s}", &c.LoggerConfig, &c.ServerConfig, &c.RateLimitConfig)
 }
 
 func (l *LoggerConfig) Validate() error {
@@ -98,11 +308,31 @@ func (l *LoggerConfig) Validate() error {
 		return ErrInvalidLogLevel
 	}
 
+	if len(l.Outputs) == 0 {
+		return ErrInvalidLogOutput
+	}
+
+	if slices.Contains(l.Outputs, LogOutputFile) && l.File.Path == "" {
+		return fmt.Errorf("%w: file path required", ErrInvalidLogOutput)
+	}
+
+	if l.File.MaxSize < 0 || l.File.RotateEvery < 0 || l.File.MaxBackups < 0 || l.File.MaxAge < 0 {
+		return fmt.Errorf("%w: file rotation settings must not be negative", ErrInvalidLogOutput)
+	}
+
+	if l.Sampling.Enabled && (l.Sampling.Interval <= 0 || l.Sampling.First < 0 || l.Sampling.Thereafter < 1) {
+		return fmt.Errorf("%w: sampling interval and thereafter must be positive", ErrInvalidLogOutput)
+	}
+
+	if l.AdminPath != "" && !strings.HasPrefix(l.AdminPath, "/") {
+		return fmt.Errorf("%w: admin_path must start with /", ErrInvalidLogOutput)
+	}
+
 	return nil
 }
 
 func (l *LoggerConfig) String() string {
-	return fmt.Sprintf("{Format: %s, Level: %s}", l.Format, l.Level)
+	return fmt.Sprintf("{Format: %s, Level: %s, Outputs: %v, File: %s}", l.Format, l.Level, l.Outputs, l.File.Path)
 }
 
 func (s *ServerConfig) Validate() error {
@@ -114,11 +344,299 @@ func (s *ServerConfig) Validate() error {
 		return ErrInvalidServerPort
 	}
 
+	if s.ReadTimeout <= 0 || s.WriteTimeout <= 0 || s.ShutdownTimeout <= 0 || s.DrainDelay < 0 {
+		return ErrInvalidServerTimeout
+	}
+
+	serving := 0
+	systemd := make(map[string]bool)
+
+	for _, listener := range s.Listeners {
+		if err := listener.Validate(); err != nil {
+			return err
+		}
+
+		if !listener.Redirect {
+			serving++
+		}
+
+		if listener.Network == ListenerSystemd {
+			if systemd[listener.Address] {
+				return fmt.Errorf("%w: systemd listeners need distinct socket names", ErrInvalidListener)
+			}
+
+			systemd[listener.Address] = true
+		}
+	}
+
+	// an empty name takes every inherited socket
+	if len(systemd) > 1 && systemd[""] {
+		return fmt.Errorf("%w: systemd listeners need distinct socket names", ErrInvalidListener)
+	}
+
+	if len(s.Listeners) > 0 && serving == 0 {
+		return fmt.Errorf("%w: at least one listener must not redirect", ErrInvalidListener)
+	}
+
+	if serving < len(s.Listeners) && !s.TLS.Enabled {
+		return fmt.Errorf("%w: redirect listeners require tls", ErrInvalidListener)
+	}
+
+	if len(s.Listeners) > 0 && s.TLS.RedirectPort != 0 {
+		return fmt.Errorf("%w: redirect_port is not used with listeners, add a redirect listener", ErrInvalidTLSConfig)
+	}
+
+	return s.TLS.Validate()
+}
+
+// Addresses returns the configured listeners, falling back to a tcp listener on
+// host and port and a redirect listener on host and the tls redirect port.
+func (s *ServerConfig) Addresses() []ListenerConfig {
+	if len(s.Listeners) > 0 {
+		return s.Listeners
+	}
+
+	listeners := []ListenerConfig{{Network: ListenerTCP, Address: net.JoinHostPort(string(s.Host), strconv.Itoa(int(s.Port)))}}
+
+	if s.TLS.Enabled && s.TLS.RedirectPort != 0 {
+		address := net.JoinHostPort(string(s.Host), strconv.Itoa(s.TLS.RedirectPort))
+		listeners = append(listeners, ListenerConfig{Network: ListenerTCP, Address: address, Redirect: true})
+	}
+
+	return listeners
+}
+
+// Validate checks the listener; systemd listeners select the inherited socket by LISTEN_FDNAMES
+// name, an empty address takes all of them.
+func (l *ListenerConfig) Validate() error {
+	switch l.Network {
+	case ListenerTCP, ListenerTCP4, ListenerTCP6:
+		host, port, err := net.SplitHostPort(l.Address)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidListener, l.Address, err)
+		}
+
+		if host != "" && net.ParseIP(host) == nil && !validHostname(host) {
+			return fmt.Errorf("%w: %s: invalid host", ErrInvalidListener, l.Address)
+		}
+
+		if number, err := strconv.Atoi(port); err != nil || number < 0 || number > 65535 {
+			return fmt.Errorf("%w: %s: invalid port", ErrInvalidListener, l.Address)
+		}
+	case ListenerUnix:
+		if l.Address == "" {
+			return fmt.Errorf("%w: unix socket path required", ErrInvalidListener)
+		}
+
+		if _, err := l.FileMode(); err != nil {
+			return err
+		}
+	case ListenerSystemd:
+	default:
+		return fmt.Errorf("%w: network %q", ErrInvalidListener, l.Network)
+	}
+
 	return nil
 }
 
+// FileMode parses the octal unix socket permissions; zero means the umask default.
+func (l *ListenerConfig) FileMode() (os.FileMode, error) {
+	if l.Mode == "" {
+		return 0, nil
+	}
+
+	mode, err := strconv.ParseUint(l.Mode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("%w: mode %q", ErrInvalidListener, l.Mode)
+	}
+
+	return os.FileMode(mode), nil
+}
+
+func (l ListenerConfig) String() string {
+	if l.Redirect {
+		return string(l.Network) + "://" + l.Address + " (redirect)"
+	}
+
+	return string(l.Network) + "://" + l.Address
+}
+
 func (s *ServerConfig) String() string {
-	return fmt.Sprintf("{Host: %s, Port: %d}", s.Host, s.Port)
+	return fmt.Sprintf(
+		"{Host: %s, Port: %d, ReadTimeout: %s, WriteTimeout: %s, DrainDelay: %s, ShutdownTimeout: %s}",
+		s.Host, s.Port, s.ReadTimeout, s.WriteTimeout, s.DrainDelay, s.ShutdownTimeout,
+	)
+}
+
+func (t *TLSConfig) Validate() error {
+	if !t.Enabled {
+		return nil
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") || (t.CertFile == "" && !t.SelfSigned) {
+		return fmt.Errorf("%w: cert_file and key_file or self_signed required", ErrInvalidTLSConfig)
+	}
+
+	if t.ClientAuth == ClientAuthRequire && t.ClientCAFile == "" {
+		return fmt.Errorf("%w: client_ca_file required", ErrInvalidTLSConfig)
+	}
+
+	if t.RedirectPort != 0 && t.RedirectPort < 1024 { //nolint:mnd
+		return fmt.Errorf("%w: redirect_port must be >= 1024", ErrInvalidTLSConfig)
+	}
+
+	if t.ReloadInterval <= 0 {
+		return fmt.Errorf("%w: reload_interval must be positive", ErrInvalidTLSConfig)
+	}
+
+	return nil
+}
+
+func (t *TLSConfig) String() string {
+	return fmt.Sprintf(
+		"{Enabled: %t, CertFile: %s, KeyFile: %s, SelfSigned: %t, MinVersion: %s, ClientAuth: %s, HTTP2: %t, RedirectPort: %d}",
+		t.Enabled, t.CertFile, t.KeyFile, t.SelfSigned, t.MinVersion, t.ClientAuth, t.HTTP2, t.RedirectPort,
+	)
+}
+
+func (r *RateLimitConfig) Validate() error {
+	if r.MaxInFlight < 0 {
+		return fmt.Errorf("%w: max_in_flight must not be negative", ErrInvalidRateLimit)
+	}
+
+	if !r.Enabled {
+		return nil
+	}
+
+	if r.Rate <= 0 || r.Burst < 1 {
+		return fmt.Errorf("%w: rate and burst must be positive", ErrInvalidRateLimit)
+	}
+
+	if r.Key == RateLimitKeyHeader && r.Header == "" {
+		return fmt.Errorf("%w: header required", ErrInvalidRateLimit)
+	}
+
+	if r.IdleTimeout <= 0 {
+		return fmt.Errorf("%w: idle_timeout must be positive", ErrInvalidRateLimit)
+	}
+
+	return nil
+}
+
+func (r *RateLimitConfig) String() string {
+	return fmt.Sprintf(
+		"{Enabled: %t, Rate: %g, Burst: %d, Key: %s, Header: %s, MaxInFlight: %d}",
+		r.Enabled, r.Rate, r.Burst, r.Key, r.Header, r.MaxInFlight,
+	)
+}
+
+func (f *FaultConfig) Validate() error {
+	names := make(map[string]bool, len(f.Rules))
+
+	for _, rule := range f.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+
+		if names[rule.Name] {
+			return fmt.Errorf("%w: duplicate name %s", ErrInvalidFaultRule, rule.Name)
+		}
+
+		names[rule.Name] = true
+	}
+
+	if f.Enabled && f.AdminPath != "" && !strings.HasPrefix(f.AdminPath, "/") {
+		return fmt.Errorf("%w: admin_path must start with /", ErrInvalidFaultRule)
+	}
+
+	return nil
+}
+
+// Validate checks the rule; zero probability means the rule always fires.
+func (r *FaultRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: name required", ErrInvalidFaultRule)
+	}
+
+	if r.Status != 0 && http.StatusText(r.Status) == "" {
+		return fmt.Errorf("%w: %s: invalid status %d", ErrInvalidFaultRule, r.Name, r.Status)
+	}
+
+	if r.Probability < 0 || r.Probability > 1 || r.Delay < 0 {
+		return fmt.Errorf("%w: %s: probability must be in [0, 1], delay must not be negative", ErrInvalidFaultRule, r.Name)
+	}
+
+	return nil
+}
+
+func (s *StaticConfig) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+
+	if !strings.HasPrefix(s.Prefix, "/") || !strings.HasSuffix(s.Prefix, "/") {
+		return fmt.Errorf("%w: prefix must start and end with /", ErrInvalidStaticConfig)
+	}
+
+	if s.Index == "" || strings.Contains(s.Index, "/") {
+		return fmt.Errorf("%w: index must be a file name", ErrInvalidStaticConfig)
+	}
+
+	if s.MaxAge < 0 {
+		return fmt.Errorf("%w: max_age must not be negative", ErrInvalidStaticConfig)
+	}
+
+	return nil
+}
+
+func (c *CompressionConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Level < 1 || c.Level > 9 || c.MinSize < 0 {
+		return fmt.Errorf("%w: level must be 1-9, min_size must not be negative", ErrInvalidCompressionConfig)
+	}
+
+	return nil
+}
+
+func (c *CORSConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if len(c.AllowedOrigins) == 0 || len(c.AllowedMethods) == 0 {
+		return fmt.Errorf("%w: allowed_origins and allowed_methods required", ErrInvalidCORSConfig)
+	}
+
+	if c.AllowCredentials && slices.Contains(c.AllowedOrigins, "*") {
+		return fmt.Errorf("%w: credentials can't be allowed for any origin", ErrInvalidCORSConfig)
+	}
+
+	return nil
+}
+
+func (a *AuthConfig) Validate() error {
+	if !a.Enabled {
+		return nil
+	}
+
+	if len(a.APIKeys) == 0 && a.HtpasswdFile == "" && a.JWT.Secret == "" && a.JWT.JWKSFile == "" {
+		return fmt.Errorf("%w: at least one verifier required", ErrInvalidAuthConfig)
+	}
+
+	for _, key := range a.APIKeys {
+		if key.Name == "" || key.Key == "" {
+			return fmt.Errorf("%w: api key name and key required", ErrInvalidAuthConfig)
+		}
+	}
+
+	if a.APIKeyHeader == "" || a.JWT.Leeway < 0 {
+		return fmt.Errorf("%w: api_key_header required, jwt leeway must not be negative", ErrInvalidAuthConfig)
+	}
+
+	return nil
 }
 
 func (lf *LogFormat) UnmarshalYAML(node *yaml.Node) error {
@@ -168,6 +686,52 @@ func (ll *LogLevel) SetValue(value string) error {
 	return nil
 }
 
+func (lo *LogOutput) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	err := node.Decode(&value)
+	if err != nil {
+		return fmt.Errorf("invalid log output: %w", err)
+	}
+
+	return lo.SetValue(value)
+}
+
+func (lo *LogOutput) SetValue(value string) error {
+	outputs := []LogOutput{LogOutputStdout, LogOutputStderr, LogOutputFile}
+
+	if !slices.Contains(outputs, LogOutput(value)) {
+		return ErrInvalidLogOutput
+	}
+
+	*lo = LogOutput(value)
+
+	return nil
+}
+
+func (ln *ListenerNetwork) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	err := node.Decode(&value)
+	if err != nil {
+		return fmt.Errorf("invalid listener network: %w", err)
+	}
+
+	return ln.SetValue(value)
+}
+
+func (ln *ListenerNetwork) SetValue(value string) error {
+	networks := []ListenerNetwork{ListenerTCP, ListenerTCP4, ListenerTCP6, ListenerUnix, ListenerSystemd}
+
+	if !slices.Contains(networks, ListenerNetwork(value)) {
+		return fmt.Errorf("%w: network %q", ErrInvalidListener, value)
+	}
+
+	*ln = ListenerNetwork(value)
+
+	return nil
+}
+
 func (sp *ServerPort) UnmarshalYAML(node *yaml.Node) error {
 	var value int
 
@@ -201,12 +765,106 @@ func (sh *ServerHost) UnmarshalYAML(node *yaml.Node) error {
 }
 
 func (sh *ServerHost) SetValue(value string) error {
-	ip := net.ParseIP(value)
-	if ip == nil {
+	if ip := net.ParseIP(value); ip != nil {
+		*sh = ServerHost(ip.String())
+
+		return nil
+	}
+
+	if !validHostname(value) {
 		return ErrInvalidServerHost
 	}
 
-	*sh = ServerHost(ip.String())
+	*sh = ServerHost(value)
+
+	return nil
+}
+
+func validHostname(host string) bool {
+	if host == "" || len(host) > 253 { //nolint:mnd
+		return false
+	}
+
+	for label := range strings.SplitSeq(strings.TrimSuffix(host, "."), ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' { //nolint:mnd
+			return false
+		}
+
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (tv *TLSVersion) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	err := node.Decode(&value)
+	if err != nil {
+		return fmt.Errorf("invalid tls version: %w", err)
+	}
+
+	return tv.SetValue(value)
+}
+
+func (tv *TLSVersion) SetValue(value string) error {
+	versions := []TLSVersion{TLSVersion12, TLSVersion13}
+
+	if !slices.Contains(versions, TLSVersion(value)) {
+		return ErrInvalidTLSVersion
+	}
+
+	*tv = TLSVersion(value)
+
+	return nil
+}
+
+func (ca *ClientAuth) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	err := node.Decode(&value)
+	if err != nil {
+		return fmt.Errorf("invalid tls client auth: %w", err)
+	}
+
+	return ca.SetValue(value)
+}
+
+func (ca *ClientAuth) SetValue(value string) error {
+	modes := []ClientAuth{ClientAuthNone, ClientAuthRequest, ClientAuthRequire}
+
+	if !slices.Contains(modes, ClientAuth(value)) {
+		return ErrInvalidClientAuth
+	}
+
+	*ca = ClientAuth(value)
+
+	return nil
+}
+
+func (rk *RateLimitKey) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	err := node.Decode(&value)
+	if err != nil {
+		return fmt.Errorf("invalid rate limit key: %w", err)
+	}
+
+	return rk.SetValue(value)
+}
+
+func (rk *RateLimitKey) SetValue(value string) error {
+	keys := []RateLimitKey{RateLimitKeyIP, RateLimitKeyHeader, RateLimitKeyRoute}
+
+	if !slices.Contains(keys, RateLimitKey(value)) {
+		return ErrInvalidRateLimitKey
+	}
+
+	*rk = RateLimitKey(value)
 
 	return nil
 }
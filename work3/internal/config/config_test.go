@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestServerConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tcp := ListenerConfig{Network: ListenerTCP, Address: ":9443"}
+	redirect := ListenerConfig{Network: ListenerTCP, Address: ":9080", Redirect: true}
+
+	tests := []struct {
+		name      string
+		listeners []ListenerConfig
+		tls       bool
+		wantErr   error
+	}{
+		{"host and port", nil, false, nil},
+		{"redirect with tls", []ListenerConfig{tcp, redirect}, true, nil},
+		{"redirect without tls", []ListenerConfig{tcp, redirect}, false, ErrInvalidListener},
+		{"redirect only", []ListenerConfig{redirect}, true, ErrInvalidListener},
+		{"systemd names", []ListenerConfig{{Network: ListenerSystemd, Address: "http"}, {Network: ListenerSystemd, Address: "admin"}}, false, nil},
+		{"systemd all", []ListenerConfig{{Network: ListenerSystemd}}, false, nil},
+		{"systemd duplicate", []ListenerConfig{{Network: ListenerSystemd, Address: "http"}, {Network: ListenerSystemd, Address: "http"}}, false, ErrInvalidListener},
+		{"systemd empty", []ListenerConfig{{Network: ListenerSystemd, Address: "http"}, {Network: ListenerSystemd}}, false, ErrInvalidListener},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conf := ServerConfig{
+				Host:            "0.0.0.0",
+				Port:            9090,
+				Listeners:       tt.listeners,
+				ReadTimeout:     time.Second,
+				WriteTimeout:    time.Second,
+				ShutdownTimeout: time.Second,
+				TLS:             TLSConfig{Enabled: tt.tls, SelfSigned: true, ReloadInterval: time.Minute},
+			}
+
+			if err := conf.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
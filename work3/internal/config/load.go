@@ -0,0 +1,431 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceDotEnv  = "dotenv"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+
+	secretMask = "******"
+)
+
+var ErrUnsupportedConfigFormat = errors.New("unsupported config format")
+
+// field is a single configurable value, addressed by its yaml path.
+type field struct {
+	path   string
+	env    string
+	def    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// flagValue collects a raw command-line value; it is decoded after the other layers.
+type flagValue struct {
+	value   string
+	boolean bool
+}
+
+func (f *flagValue) String() string {
+	return f.value
+}
+
+// IsBoolFlag allows bool fields to be set as -flag without a value.
+func (f *flagValue) IsBoolFlag() bool {
+	return f.boolean
+}
+
+func (f *flagValue) Set(value string) error {
+	f.value = value
+
+	return nil
+}
+
+// Load builds the configuration from layered sources, each overriding the previous one:
+//
+//  1. defaults from the env-default tags;
+//  2. config file (-c), YAML, TOML or JSON by extension;
+//  3. .env file (-env-file);
+//  4. process environment;
+//  5. command-line flags, one per field named after its yaml path (-server.port).
+//
+// Missing config and .env files are skipped unless their paths are given explicitly.
+// A variable or flag set to an empty value resets the field to its zero value.
+func Load() (*Config, error) {
+	config := Config{sources: make(map[string]string)}
+	fields := config.fields()
+
+	file := flag.String("c", "config.yml", "path to config file (yaml, toml, json)")
+	envFile := flag.String("env-file", ".env", "path to .env file")
+	flag.BoolVar(&config.printConfig, "print-config", false, "print effective config with value sources and exit")
+
+	values := make(map[string]*flagValue, len(fields))
+	for _, f := range fields {
+		values[f.path] = &flagValue{boolean: f.value.Kind() == reflect.Bool}
+		flag.Var(values[f.path], f.path, f.usage)
+	}
+
+	flag.Usage = cleanenv.FUsage(flag.CommandLine.Output(), &config, nil, flag.Usage)
+	flag.Parse()
+
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	layers := []func() error{
+		func() error { return config.loadDefaults(fields) },
+		func() error { return config.loadFile(fields, *file, explicit["c"]) },
+		func() error { return config.loadDotEnv(fields, *envFile, explicit["env-file"]) },
+		func() error { return config.loadEnv(fields) },
+		func() error { return config.loadFlags(fields, values, explicit) },
+	}
+
+	for _, layer := range layers {
+		if err := layer(); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	err := config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// PrintOnly reports whether -print-config was requested.
+func (c *Config) PrintOnly() bool {
+	return c.printConfig
+}
+
+// Report writes the effective configuration, one value per line annotated with its source.
+// Secret values are masked.
+func (c *Config) Report(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	for _, f := range c.fields() {
+		source, ok := c.sources[f.path]
+		if !ok {
+			source = "unset"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t# %s\n", f.path, f.format(), source)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("config report: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadDefaults(fields []*field) error {
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+
+		if err := c.set(f, f.def, SourceDefault); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) loadFile(fields []*field, path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	root, err := decodeFile(path, data)
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	source := fmt.Sprintf("%s %s", SourceFile, path)
+
+	for _, f := range fields {
+		node := lookup(root, f.path)
+		if node == nil {
+			continue
+		}
+
+		if err := node.Decode(f.value.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+
+		c.sources[f.path] = source
+	}
+
+	return nil
+}
+
+func (c *Config) loadDotEnv(fields []*field, path string, required bool) error {
+	env, err := godotenv.Read(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("env file: %w", err)
+	}
+
+	source := fmt.Sprintf("%s %s", SourceDotEnv, path)
+
+	for _, f := range fields {
+		value, ok := env[f.env]
+		if f.env == "" || !ok {
+			continue
+		}
+
+		if err := c.set(f, value, source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv(fields []*field) error {
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+
+		value, ok := os.LookupEnv(f.env)
+		if !ok {
+			continue
+		}
+
+		if err := c.set(f, value, fmt.Sprintf("%s %s", SourceEnv, f.env)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) loadFlags(fields []*field, values map[string]*flagValue, explicit map[string]bool) error {
+	for _, f := range fields {
+		if !explicit[f.path] {
+			continue
+		}
+
+		if err := c.set(f, values[f.path].value, fmt.Sprintf("%s -%s", SourceFlag, f.path)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// set decodes a raw string value into the field through yaml, so custom
+// UnmarshalYAML validation applies to every source. An empty value resets the
+// field to its zero value.
+func (c *Config) set(f *field, value, source string) error {
+	if value == "" {
+		f.value.SetZero()
+		c.sources[f.path] = source
+
+		return nil
+	}
+
+	node, err := valueNode(f.value.Type(), value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	if err := node.Decode(f.value.Addr().Interface()); err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	c.sources[f.path] = source
+
+	return nil
+}
+
+// fields lists the configurable leaves of the config.
+func (c *Config) fields() []*field {
+	return collect(reflect.ValueOf(c).Elem(), "")
+}
+
+func collect(v reflect.Value, prefix string) []*field {
+	fields := make([]*field, 0)
+	unmarshaler := reflect.TypeFor[yaml.Unmarshaler]()
+
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if !sf.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		path := prefix + name
+
+		if sf.Type.Kind() == reflect.Struct && !reflect.PointerTo(sf.Type).Implements(unmarshaler) {
+			fields = append(fields, collect(v.Field(i), path+".")...)
+
+			continue
+		}
+
+		fields = append(fields, &field{
+			path:   path,
+			env:    sf.Tag.Get("env"),
+			def:    sf.Tag.Get("env-default"),
+			usage:  sf.Tag.Get("env-description"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+func (f *field) format() string {
+	if f.secret {
+		if empty(f.value) {
+			return ""
+		}
+
+		return secretMask
+	}
+
+	switch f.value.Kind() { //nolint:exhaustive
+	case reflect.Slice, reflect.Map, reflect.Struct:
+		data, err := json.Marshal(f.value.Interface())
+		if err == nil {
+			return string(data)
+		}
+	}
+
+	return fmt.Sprint(f.value.Interface())
+}
+
+// empty reports whether the value is zero or an empty slice, map or string.
+func empty(v reflect.Value) bool {
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	}
+
+	return v.IsZero()
+}
+
+// valueNode turns a raw string into a yaml node: scalar slices are comma separated,
+// structured values are parsed as inline yaml/json.
+func valueNode(typ reflect.Type, value string) (*yaml.Node, error) {
+	if reflect.PointerTo(typ).Implements(reflect.TypeFor[yaml.Unmarshaler]()) {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value}, nil
+	}
+
+	switch typ.Kind() { //nolint:exhaustive
+	case reflect.Slice:
+		switch typ.Elem().Kind() { //nolint:exhaustive
+		case reflect.Struct, reflect.Map, reflect.Slice:
+			return parseNode([]byte(value))
+		}
+
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for item := range strings.SplitSeq(value, ",") {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(item)})
+		}
+
+		return node, nil
+	case reflect.Map, reflect.Struct:
+		return parseNode([]byte(value))
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}, nil
+}
+
+func parseNode(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse value: %w", err)
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode}, nil
+	}
+
+	return doc.Content[0], nil
+}
+
+// decodeFile parses a config file into a yaml tree; toml and json are normalized through yaml.
+func decodeFile(path string, data []byte) (*yaml.Node, error) {
+	var value any
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		return parseNode(data)
+	case ".json":
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("parse json: %w", err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("parse toml: %w", err)
+		}
+	default:
+		return nil, ErrUnsupportedConfigFormat
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("normalize config: %w", err)
+	}
+
+	return parseNode(data)
+}
+
+func lookup(node *yaml.Node, path string) *yaml.Node {
+	for key := range strings.SplitSeq(path, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		var next *yaml.Node
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		node = next
+	}
+
+	return node
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestConfig(t *testing.T) (*Config, []*field) {
+	t.Helper()
+
+	c := &Config{sources: make(map[string]string)}
+	fields := c.fields()
+
+	if err := c.loadDefaults(fields); err != nil {
+		t.Fatal(err)
+	}
+
+	return c, fields
+}
+
+func report(t *testing.T, c *Config) map[string]string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := c.Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make(map[string]string)
+
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		path, rest, _ := strings.Cut(line, " ")
+		lines[path] = strings.Join(strings.Fields(rest), " ")
+	}
+
+	return lines
+}
+
+func TestLoadEnvEmptyValue(t *testing.T) {
+	t.Setenv("STATIC_PREFIX", "")
+	t.Setenv("COMPRESSION_CONTENT_TYPES", "")
+	t.Setenv("RATE_LIMIT_RATE", "2.5")
+
+	c, fields := newTestConfig(t)
+	if err := c.loadEnv(fields); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.StaticConfig.Prefix != "" || len(c.CompressionConfig.ContentTypes) != 0 {
+		t.Errorf("empty env ignored: prefix %q, content types %q", c.StaticConfig.Prefix, c.CompressionConfig.ContentTypes)
+	}
+
+	if c.RateLimitConfig.Rate != 2.5 {
+		t.Errorf("rate = %v", c.RateLimitConfig.Rate)
+	}
+
+	if source := c.sources["static.prefix"]; source != "env STATIC_PREFIX" {
+		t.Errorf("source = %q", source)
+	}
+}
+
+func TestLoadDotEnvEmptyValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("STATIC_PREFIX=\nLOG_LEVEL=debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, fields := newTestConfig(t)
+	if err := c.loadDotEnv(fields, path, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.StaticConfig.Prefix != "" || c.LoggerConfig.Level != LogLevelDebug {
+		t.Errorf("prefix %q, level %q", c.StaticConfig.Prefix, c.LoggerConfig.Level)
+	}
+}
+
+func TestReportMasksSecrets(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newTestConfig(t)
+	c.AuthConfig.APIKeys = []APIKeyConfig{}
+
+	lines := report(t, c)
+	if lines["auth.api_keys"] != "# unset" || lines["auth.jwt.secret"] != "# unset" {
+		t.Errorf("empty secrets: %q, %q", lines["auth.api_keys"], lines["auth.jwt.secret"])
+	}
+
+	c.AuthConfig.APIKeys = []APIKeyConfig{{Name: "ci", Key: "s3cr3t"}}
+	c.AuthConfig.JWT.Secret = "s3cr3t"
+
+	lines = report(t, c)
+	if !strings.HasPrefix(lines["auth.api_keys"], secretMask) || !strings.HasPrefix(lines["auth.jwt.secret"], secretMask) {
+		t.Errorf("secrets not masked: %q, %q", lines["auth.api_keys"], lines["auth.jwt.secret"])
+	}
+
+	if lines["server.port"] != "9090 # default" {
+		t.Errorf("server.port = %q", lines["server.port"])
+	}
+}
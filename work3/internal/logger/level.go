@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// LevelHandler reports the current level on GET and changes it on PUT/POST with ?level=debug|info|warn|error.
+func (l *Logger) LevelHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPut || req.Method == http.MethodPost {
+		var level config.LogLevel
+
+		if err := level.SetValue(req.URL.Query().Get("level")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		previous := l.level.Level()
+		l.level.Set(loggerLevel(level))
+		l.Warn("log level changed", slog.String("from", previous.String()), slog.String("to", l.level.Level().String()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"level": strings.ToLower(l.level.Level().String())})
+}
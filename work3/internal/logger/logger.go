@@ -1,29 +1,82 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
 	"github.com/mch735/education/work3/internal/config"
 )
 
-func NewLogger(conf config.LoggerConfig) (*slog.Logger, error) {
+// Logger is a slog.Logger writing to the configured sinks with a level that can
+// be changed at runtime.
+type Logger struct {
+	*slog.Logger
+
+	level   *slog.LevelVar
+	closers []io.Closer
+}
+
+func NewLogger(conf config.LoggerConfig) (*Logger, error) {
 	if err := conf.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid logger settings: %w", err)
 	}
 
-	return logger(conf), nil
+	return logger(conf)
+}
+
+// Close closes file sinks.
+func (l *Logger) Close() error {
+	errs := make([]error, 0, len(l.closers))
+	for _, closer := range l.closers {
+		errs = append(errs, closer.Close())
+	}
+
+	return errors.Join(errs...)
 }
 
-func logger(conf config.LoggerConfig) *slog.Logger {
-	level := loggerLevel(conf.Level)
+func logger(conf config.LoggerConfig) (*Logger, error) {
+	l := &Logger{level: new(slog.LevelVar)}
+	l.level.Set(loggerLevel(conf.Level))
+
+	writers := make([]io.Writer, 0, len(conf.Outputs))
 
+	for _, output := range conf.Outputs {
+		switch output {
+		case config.LogOutputStdout:
+			writers = append(writers, os.Stdout)
+		case config.LogOutputStderr:
+			writers = append(writers, os.Stderr)
+		case config.LogOutputFile:
+			file, err := newRotatingFile(conf.File)
+			if err != nil {
+				_ = l.Close()
+
+				return nil, fmt.Errorf("log file: %w", err)
+			}
+
+			writers = append(writers, file)
+			l.closers = append(l.closers, file)
+		}
+	}
+
+	out := io.MultiWriter(writers...)
+	options := &slog.HandlerOptions{Level: l.level}
+
+	var handler slog.Handler = slog.NewTextHandler(out, options)
 	if conf.Format == config.LogFormatJSON {
-		return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+		handler = slog.NewJSONHandler(out, options)
+	}
+
+	if conf.Sampling.Enabled {
+		handler = newSamplingHandler(handler, conf.Sampling)
 	}
 
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	l.Logger = slog.New(handler)
+
+	return l, nil
 }
 
 func loggerLevel(level config.LogLevel) slog.Level {
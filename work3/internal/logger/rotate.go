@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+const (
+	megabyte     = 1 << 20
+	backupLayout = "20060102T150405.000"
+)
+
+// rotatingFile is a log file rotated by size and age. Rotated files are renamed
+// to <path>.<timestamp> and pruned by count and age.
+type rotatingFile struct {
+	path        string
+	maxSize     int64
+	rotateEvery time.Duration
+	maxBackups  int
+	maxAge      time.Duration
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+func newRotatingFile(conf config.LogFileConfig) (*rotatingFile, error) {
+	f := &rotatingFile{
+		path:        conf.Path,
+		maxSize:     int64(conf.MaxSize) * megabyte,
+		rotateEvery: conf.RotateEvery,
+		maxBackups:  conf.MaxBackups,
+		maxAge:      conf.MaxAge,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) Write(data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.due(int64(len(data))) {
+		if err := f.rotate(); err != nil {
+			// keep logging to the current file, rotation is retried on the next write
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close() //nolint:wrapcheck
+}
+
+func (f *rotatingFile) due(size int64) bool {
+	if f.maxSize > 0 && f.size > 0 && f.size+size > f.maxSize {
+		return true
+	}
+
+	return f.rotateEvery > 0 && time.Since(f.opened) >= f.rotateEvery
+}
+
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil { //nolint:mnd
+		return fmt.Errorf("create log dir: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644) //nolint:mnd
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = f.created(info)
+
+	return nil
+}
+
+// created returns when the current file was started, so the rotation interval
+// survives restarts: the time of the newest backup, which is when the file was
+// rotated last, or the file mtime when there is no backup.
+func (f *rotatingFile) created(info os.FileInfo) time.Time {
+	if info.Size() == 0 {
+		return time.Now()
+	}
+
+	backups, _ := filepath.Glob(f.path + ".*")
+
+	var newest time.Time
+
+	for _, backup := range backups {
+		stamp, err := time.ParseInLocation(backupLayout, strings.TrimPrefix(backup, f.path+"."), time.Local)
+		if err == nil && stamp.After(newest) {
+			newest = stamp
+		}
+	}
+
+	if newest.IsZero() || newest.After(info.ModTime()) {
+		return info.ModTime()
+	}
+
+	return newest
+}
+
+// rotate renames the file while it is still open and swaps the handle only
+// once the new file is opened; on failure the current file stays in use.
+func (f *rotatingFile) rotate() error {
+	backup := f.path + "." + time.Now().Format(backupLayout)
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+
+	current := f.file
+
+	if err := f.open(); err != nil {
+		_ = os.Rename(backup, f.path)
+
+		return err
+	}
+
+	_ = current.Close()
+
+	f.prune()
+
+	return nil
+}
+
+// prune removes backups beyond the count and age limits; failures are ignored
+// to keep logging.
+func (f *rotatingFile) prune() {
+	backups, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return
+	}
+
+	// timestamps sort lexicographically, newest first after reversing
+	slices.Sort(backups)
+	slices.Reverse(backups)
+
+	kept := 0
+
+	for _, backup := range backups {
+		stamp, err := time.ParseInLocation(backupLayout, strings.TrimPrefix(backup, f.path+"."), time.Local)
+		if err != nil {
+			continue
+		}
+
+		if (f.maxBackups > 0 && kept >= f.maxBackups) || (f.maxAge > 0 && time.Since(stamp) > f.maxAge) {
+			_ = os.Remove(backup)
+
+			continue
+		}
+
+		kept++
+	}
+}
@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := newRotatingFile(config.LogFileConfig{Path: path, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.maxSize = 10
+
+	for range 5 {
+		if _, err := f.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// backups rotated within the same millisecond share a name
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) == 0 || len(backups) > 2 {
+		t.Errorf("backups = %d, want 1 to 2", len(backups))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "0123456789" {
+		t.Errorf("current file = %q, %v", data, err)
+	}
+}
+
+func TestRotatingFileRenameFailure(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := newRotatingFile(config.LogFileConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.maxSize = 10
+
+	if _, err := f.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rename fails, writes go on to the open file
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("write after failed rotation: %v", err)
+	}
+
+	if f.size != 20 {
+		t.Errorf("size = %d, want 20", f.size)
+	}
+
+	// rotation recovers once the file is back
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "0123456789" {
+		t.Errorf("current file = %q, %v", data, err)
+	}
+}
+
+func TestRotatingFileRotateEveryResume(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		backup time.Duration // age of the newest backup, 0 for none
+		mtime  time.Duration
+		rotate bool
+	}{
+		{"old file", 0, 2 * time.Hour, true},
+		{"recent file", 0, time.Minute, false},
+		{"old backup", 2 * time.Hour, time.Minute, true},
+		{"recent backup", time.Minute, time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "app.log")
+			now := time.Now()
+
+			if tt.backup > 0 {
+				backup := path + "." + now.Add(-tt.backup).Format(backupLayout)
+				if err := os.WriteFile(backup, []byte("old\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the file was written before the restart
+			if err := os.WriteFile(path, []byte("before\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.Chtimes(path, now, now.Add(-tt.mtime)); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := newRotatingFile(config.LogFileConfig{Path: path, RotateEvery: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if _, err := f.Write([]byte("after\n")); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rotated := string(data) == "after\n"; rotated != tt.rotate {
+				t.Errorf("rotated = %t, want %t", rotated, tt.rotate)
+			}
+		})
+	}
+}
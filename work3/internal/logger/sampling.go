@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// samplingHandler passes the first messages of a window and every n-th after
+// that; records above info are never sampled.
+type samplingHandler struct {
+	slog.Handler
+	sampler *sampler
+}
+
+type sampler struct {
+	interval   time.Duration
+	first      int
+	thereafter int
+
+	mu     sync.Mutex
+	window time.Time
+	counts map[string]int
+}
+
+func newSamplingHandler(h slog.Handler, conf config.LogSamplingConfig) *samplingHandler {
+	return &samplingHandler{
+		Handler: h,
+		sampler: &sampler{
+			interval:   conf.Interval,
+			first:      conf.First,
+			thereafter: conf.Thereafter,
+			counts:     make(map[string]int),
+		},
+	}
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level > slog.LevelInfo || h.sampler.allow(record.Message, record.Time) {
+		return h.Handler.Handle(ctx, record) //nolint:wrapcheck
+	}
+
+	return nil
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
+
+func (s *sampler) allow(message string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.window) >= s.interval {
+		s.window = now
+		clear(s.counts)
+	}
+
+	s.counts[message]++
+	n := s.counts[message]
+
+	return n <= s.first || (n-s.first)%s.thereafter == 0
+}
@@ -0,0 +1,330 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// DefaultBuckets are latency buckets in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10} //nolint:mnd
+
+// Registry keeps metric families and renders them in the Prometheus text exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	families []writer
+}
+
+type writer interface {
+	write(w *bufio.Writer)
+}
+
+// family is a metric with a fixed set of label names and one series per label values.
+type family[T any] struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+	create func() *T
+
+	mu     sync.Mutex
+	series map[string]*T
+	values map[string][]string
+}
+
+type Counter struct {
+	family *family[value]
+}
+
+type Gauge struct {
+	family *family[value]
+}
+
+type Histogram struct {
+	family  *family[histogram]
+	buckets []float64
+}
+
+type value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: newFamily(name, help, typeCounter, labels, func() *value { return &value{} })}
+	r.register(c)
+
+	return c
+}
+
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{family: newFamily(name, help, typeGauge, labels, func() *value { return &value{} })}
+	r.register(g)
+
+	return g
+}
+
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = slices.Sorted(slices.Values(buckets))
+
+	h := &Histogram{
+		family: newFamily(name, help, typeHistogram, labels, func() *histogram {
+			return &histogram{counts: make([]uint64, len(buckets))}
+		}),
+		buckets: buckets,
+	}
+	r.register(h)
+
+	return h
+}
+
+// WriteTo renders all registered metrics.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	for _, f := range families {
+		f.write(bw)
+	}
+
+	err := bw.Flush()
+	if err != nil {
+		return cw.n, fmt.Errorf("metrics write: %w", err)
+	}
+
+	return cw.n, nil
+}
+
+// Handler serves the registry for scraping.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = r.WriteTo(w)
+	})
+}
+
+func (r *Registry) register(f writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.families = append(r.families, f)
+}
+
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *Counter) Add(delta float64, labels ...string) {
+	c.family.get(labels).add(delta)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.family.write(w, func(w *bufio.Writer, name, labels string, v *value) {
+		writeSample(w, name, labels, v.load())
+	})
+}
+
+func (g *Gauge) Set(v float64, labels ...string) {
+	g.family.get(labels).set(v)
+}
+
+func (g *Gauge) Add(delta float64, labels ...string) {
+	g.family.get(labels).add(delta)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.family.write(w, func(w *bufio.Writer, name, labels string, v *value) {
+		writeSample(w, name, labels, v.load())
+	})
+}
+
+func (h *Histogram) Observe(v float64, labels ...string) {
+	s := h.family.get(labels)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.family.write(w, func(w *bufio.Writer, name, labels string, s *histogram) {
+		s.mu.Lock()
+		counts, sum, count := slices.Clone(s.counts), s.sum, s.count
+		s.mu.Unlock()
+
+		for i, bound := range h.buckets {
+			writeSample(w, name+"_bucket", withLabel(labels, "le", formatFloat(bound)), float64(counts[i]))
+		}
+
+		writeSample(w, name+"_bucket", withLabel(labels, "le", "+Inf"), float64(count))
+		writeSample(w, name+"_sum", labels, sum)
+		writeSample(w, name+"_count", labels, float64(count))
+	})
+}
+
+func newFamily[T any](name, help, typ string, labels []string, create func() *T) *family[T] {
+	return &family[T]{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		create: create,
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+	}
+}
+
+func (f *family[T]) get(values []string) *T {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.series[key]
+	if !ok {
+		s = f.create()
+		f.series[key] = s
+		f.values[key] = slices.Clone(values)
+	}
+
+	return s
+}
+
+func (f *family[T]) write(w *bufio.Writer, sample func(w *bufio.Writer, name, labels string, s *T)) {
+	f.mu.Lock()
+	keys := slices.Sorted(maps.Keys(f.series))
+	series := make([]*T, len(keys))
+	labels := make([]string, len(keys))
+
+	for i, key := range keys {
+		series[i] = f.series[key]
+		labels[i] = formatLabels(f.labels, f.values[key])
+	}
+	f.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.typ)
+
+	for i, s := range series {
+		sample(w, f.name, labels[i], s)
+	}
+}
+
+func (v *value) add(delta float64) {
+	v.mu.Lock()
+	v.v += delta
+	v.mu.Unlock()
+}
+
+func (v *value) set(x float64) {
+	v.mu.Lock()
+	v.v = x
+	v.mu.Unlock()
+}
+
+func (v *value) load() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.v
+}
+
+func writeSample(w *bufio.Writer, name, labels string, v float64) {
+	if labels == "" {
+		fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+	} else {
+		fmt.Fprintf(w, "%s{%s} %s\n", name, labels, formatFloat(v))
+	}
+}
+
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+	if labels == "" {
+		return pair
+	}
+
+	return labels + "," + pair
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
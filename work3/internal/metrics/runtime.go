@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// runtimeStats exposes Go runtime statistics collected at scrape time.
+type runtimeStats struct {
+	start time.Time
+}
+
+// RegisterRuntime adds Go runtime and process metrics to the registry.
+func (r *Registry) RegisterRuntime() {
+	r.register(&runtimeStats{start: time.Now()})
+}
+
+func (rs *runtimeStats) write(w *bufio.Writer) {
+	var stats runtime.MemStats
+
+	runtime.ReadMemStats(&stats)
+
+	samples := []struct {
+		name, help, typ string
+		value           float64
+	}{
+		{"go_goroutines", "Number of goroutines that currently exist.", typeGauge, float64(runtime.NumGoroutine())},
+		{"go_sched_gomaxprocs_threads", "The current runtime.GOMAXPROCS setting.", typeGauge, float64(runtime.GOMAXPROCS(0))},
+		{"go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", typeGauge, float64(stats.Alloc)},
+		{"go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", typeCounter, float64(stats.TotalAlloc)},
+		{"go_memstats_sys_bytes", "Number of bytes obtained from system.", typeGauge, float64(stats.Sys)},
+		{"go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", typeGauge, float64(stats.HeapInuse)},
+		{"go_memstats_heap_objects", "Number of allocated objects.", typeGauge, float64(stats.HeapObjects)},
+		{"go_gc_cycles_total", "Number of completed GC cycles.", typeCounter, float64(stats.NumGC)},
+		{"go_gc_pause_seconds_total", "Total GC stop-the-world pause time.", typeCounter, float64(stats.PauseTotalNs) / float64(time.Second)},
+		{"process_start_time_seconds", "Start time of the process since unix epoch in seconds.", typeGauge, float64(rs.start.Unix())},
+	}
+
+	for _, s := range samples {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.name, s.help, s.name, s.typ)
+		writeSample(w, s.name, "", s.value)
+	}
+
+	fmt.Fprintf(w, "# HELP go_info Information about the Go environment.\n# TYPE go_info gauge\n")
+	writeSample(w, "go_info", formatLabels([]string{"version"}, []string{runtime.Version()}), 1)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// APIKeys verifies static keys sent in a header.
+type APIKeys struct {
+	header string
+	keys   []apiKey
+}
+
+type apiKey struct {
+	name   string
+	hash   [sha256.Size]byte
+	scopes []string
+}
+
+func NewAPIKeys(header string, keys []config.APIKeyConfig) *APIKeys {
+	a := &APIKeys{header: header}
+
+	for _, key := range keys {
+		a.keys = append(a.keys, apiKey{name: key.Name, hash: sha256.Sum256([]byte(key.Key)), scopes: key.Scopes})
+	}
+
+	return a
+}
+
+// Verify compares key hashes in constant time so timing doesn't leak key prefixes.
+func (a *APIKeys) Verify(req *http.Request) (*Principal, error) {
+	value := req.Header.Get(a.header)
+	if value == "" {
+		return nil, ErrNoCredentials
+	}
+
+	hash := sha256.Sum256([]byte(value))
+
+	for _, key := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], key.hash[:]) == 1 {
+			return &Principal{Subject: key.name, Method: "api_key", Scopes: key.scopes}, nil
+		}
+	}
+
+	return nil, ErrInvalidCredentials
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+var (
+	// ErrNoCredentials means the request carries no credentials for the verifier.
+	ErrNoCredentials = errors.New("no credentials")
+
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Subject string
+	Method  string
+	Scopes  []string
+	Claims  map[string]any
+}
+
+// Verifier authenticates a request; it returns ErrNoCredentials when the
+// request has no credentials it understands.
+type Verifier interface {
+	Verify(req *http.Request) (*Principal, error)
+}
+
+type principalKey struct{}
+
+// Auth tries the verifiers in order and stores the principal in the request
+// context. Requests without credentials pass through anonymously; routes
+// require authentication with RequireScopes.
+type Auth struct {
+	verifiers  []Verifier
+	challenges []string
+}
+
+func New(conf config.AuthConfig) (*Auth, error) {
+	a := &Auth{}
+
+	if len(conf.APIKeys) > 0 {
+		a.verifiers = append(a.verifiers, NewAPIKeys(conf.APIKeyHeader, conf.APIKeys))
+	}
+
+	if conf.HtpasswdFile != "" {
+		basic, err := NewBasic(conf.HtpasswdFile, conf.BasicScopes)
+		if err != nil {
+			return nil, err
+		}
+
+		a.verifiers = append(a.verifiers, basic)
+		a.challenges = append(a.challenges, fmt.Sprintf("Basic realm=%q", conf.Realm))
+	}
+
+	if conf.JWT.Secret != "" || conf.JWT.JWKSFile != "" {
+		jwt, err := NewJWT(conf.JWT)
+		if err != nil {
+			return nil, err
+		}
+
+		a.verifiers = append(a.verifiers, jwt)
+		a.challenges = append(a.challenges, fmt.Sprintf("Bearer realm=%q", conf.Realm))
+	}
+
+	return a, nil
+}
+
+func (a *Auth) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, verifier := range a.verifiers {
+			principal, err := verifier.Verify(req)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+
+			if err != nil {
+				a.challenge(w)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+				return
+			}
+
+			h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), principalKey{}, principal)))
+
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+func (a *Auth) challenge(w http.ResponseWriter) {
+	for _, challenge := range a.challenges {
+		w.Header().Add("WWW-Authenticate", challenge)
+	}
+}
+
+// FromContext returns the principal stored by Auth.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+
+	return principal, ok
+}
+
+// HasScopes reports whether the principal has every scope.
+func (p *Principal) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(p.Scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Scopes is a route middleware answering 401 to anonymous requests and 403 to
+// principals missing any of the scopes.
+type Scopes []string
+
+// RequireScopes returns a route middleware demanding authentication and the scopes.
+func RequireScopes(scopes ...string) Scopes {
+	return Scopes(scopes)
+}
+
+func (s Scopes) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		principal, ok := FromContext(req.Context())
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		if !principal.HasScopes(s...) {
+			http.Error(w, "missing scopes: "+strings.Join(s, " "), http.StatusForbidden)
+
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
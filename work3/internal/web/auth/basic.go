@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Basic verifies HTTP Basic credentials against a bcrypt htpasswd file.
+// Unknown users are checked against a dummy hash of the same cost, so response
+// times don't reveal which users exist.
+type Basic struct {
+	users  map[string][]byte
+	dummy  []byte
+	scopes []string
+}
+
+func NewBasic(path string, scopes []string) (*Basic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("htpasswd: %w", err)
+	}
+	defer file.Close()
+
+	b := &Basic{users: make(map[string][]byte), scopes: scopes}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		user, hash, found := strings.Cut(text, ":")
+		if !found || !strings.HasPrefix(hash, "$2") {
+			return nil, fmt.Errorf("htpasswd %s:%d: only bcrypt entries are supported", path, line)
+		}
+
+		b.users[user] = []byte(hash)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("htpasswd: %w", err)
+	}
+
+	cost := 0
+	for _, hash := range b.users {
+		if c, err := bcrypt.Cost(hash); err == nil {
+			cost = max(cost, c)
+		}
+	}
+
+	b.dummy, err = bcrypt.GenerateFromPassword([]byte("dummy"), cost)
+	if err != nil {
+		return nil, fmt.Errorf("htpasswd: %w", err)
+	}
+
+	return b, nil
+}
+
+func (b *Basic) Verify(req *http.Request) (*Principal, error) {
+	user, password, ok := req.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	hash, ok := b.users[user]
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(b.dummy, []byte(password))
+
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{Subject: user, Method: "basic", Scopes: b.scopes}, nil
+}
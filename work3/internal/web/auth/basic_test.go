@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasic(t *testing.T) {
+	t.Parallel()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte("# users\nalice:"+string(hash)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	basic, err := NewBasic(path, []string{"read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		wantErr  error
+	}{
+		{"valid", "alice", "secret", nil},
+		{"wrong password", "alice", "wrong", ErrInvalidCredentials},
+		{"unknown user", "bob", "secret", ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(tt.user, tt.password)
+
+		principal, err := basic.Verify(req)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Verify() = %v, want %v", tt.name, err, tt.wantErr)
+		}
+
+		if err == nil && (principal.Subject != "alice" || principal.Method != "basic") {
+			t.Errorf("%s: principal = %+v", tt.name, principal)
+		}
+	}
+
+	if _, err := basic.Verify(httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no credentials: %v", err)
+	}
+}
+
+func TestBasicRejectsPlainEntries(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte("alice:secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewBasic(path, nil); err == nil {
+		t.Error("plain text entry accepted")
+	}
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// JWT verifies bearer tokens signed with HS256 by a shared secret or with
+// RS256 by a key from a JWKS file, and checks exp, nbf, iss and aud. Tokens
+// without exp are rejected unless RequireExp is off.
+type JWT struct {
+	secret     []byte
+	keys       map[string]*rsa.PublicKey
+	issuer     string
+	audience   string
+	requireExp bool
+	leeway     time.Duration
+	scopeClaim string
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func NewJWT(conf config.JWTConfig) (*JWT, error) {
+	j := &JWT{
+		secret:     []byte(conf.Secret),
+		keys:       make(map[string]*rsa.PublicKey),
+		issuer:     conf.Issuer,
+		audience:   conf.Audience,
+		requireExp: conf.RequireExp,
+		leeway:     conf.Leeway,
+		scopeClaim: conf.ScopeClaim,
+	}
+
+	if conf.JWKSFile != "" {
+		if err := j.loadKeys(conf.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return j, nil
+}
+
+func (j *JWT) loadKeys(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return fmt.Errorf("jwks key %q: %w", key.Kid, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return fmt.Errorf("jwks key %q: %w", key.Kid, err)
+		}
+
+		j.keys[key.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	return nil
+}
+
+func (j *JWT) Verify(req *http.Request) (*Principal, error) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := j.parse(strings.TrimSpace(token), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	return &Principal{Subject: subject, Method: "jwt", Scopes: j.scopes(claims), Claims: claims}, nil
+}
+
+func (j *JWT) parse(token string, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 { //nolint:mnd
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %w", ErrInvalidToken, err)
+	}
+
+	if err := j.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err := j.validate(claims, now); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// verifySignature accepts only the algorithms backed by configured keys, so
+// "none" and algorithm confusion are rejected.
+func (j *JWT) verifySignature(header jwtHeader, signed string, signature []byte) error {
+	switch {
+	case header.Alg == "HS256" && len(j.secret) > 0:
+		mac := hmac.New(sha256.New, j.secret)
+		mac.Write([]byte(signed))
+
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	case header.Alg == "RS256" && len(j.keys) > 0:
+		key, ok := j.keys[header.Kid]
+		if !ok {
+			return fmt.Errorf("%w: unknown key %q", ErrInvalidToken, header.Kid)
+		}
+
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+
+	return nil
+}
+
+func (j *JWT) validate(claims map[string]any, now time.Time) error {
+	exp, ok := claims["exp"].(float64)
+	if !ok && j.requireExp {
+		return fmt.Errorf("%w: exp required", ErrInvalidToken)
+	}
+
+	if ok && now.After(time.Unix(int64(exp), 0).Add(j.leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0).Add(-j.leeway)) {
+		return fmt.Errorf("%w: not yet valid", ErrInvalidToken)
+	}
+
+	if iss, _ := claims["iss"].(string); j.issuer != "" && iss != j.issuer {
+		return fmt.Errorf("%w: issuer %q", ErrInvalidToken, iss)
+	}
+
+	if j.audience != "" && !slices.Contains(stringList(claims["aud"]), j.audience) {
+		return fmt.Errorf("%w: audience", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+// scopes reads a space separated scope claim or an "scp" array.
+func (j *JWT) scopes(claims map[string]any) []string {
+	if scope, ok := claims[j.scopeClaim].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	if scopes := stringList(claims[j.scopeClaim]); len(scopes) > 0 {
+		return scopes
+	}
+
+	return stringList(claims["scp"])
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return nil
+}
+
+// stringList reads a claim that is either a string or an array of strings.
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		list := make([]string, 0, len(v))
+
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+
+		return list
+	}
+
+	return nil
+}
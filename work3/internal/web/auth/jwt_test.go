@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+const testSecret = "test-secret"
+
+func signHS256(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTValidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	exp := float64(now.Add(time.Hour).Unix())
+
+	tests := []struct {
+		name       string
+		secret     string
+		claims     map[string]any
+		requireExp bool
+		wantErr    bool
+	}{
+		{"valid", testSecret, map[string]any{"sub": "alice", "exp": exp, "iss": "work3", "aud": "api"}, true, false},
+		{"audience list", testSecret, map[string]any{"exp": exp, "iss": "work3", "aud": []any{"other", "api"}}, true, false},
+		{"bad signature", "other-secret", map[string]any{"exp": exp, "iss": "work3", "aud": "api"}, true, true},
+		{"expired", testSecret, map[string]any{"exp": float64(now.Add(-time.Hour).Unix()), "iss": "work3", "aud": "api"}, true, true},
+		{"expired within leeway", testSecret, map[string]any{"exp": float64(now.Add(-10 * time.Second).Unix()), "iss": "work3", "aud": "api"}, true, false},
+		{"not yet valid", testSecret, map[string]any{"exp": exp, "nbf": float64(now.Add(time.Hour).Unix()), "iss": "work3", "aud": "api"}, true, true},
+		{"wrong issuer", testSecret, map[string]any{"exp": exp, "iss": "other", "aud": "api"}, true, true},
+		{"wrong audience", testSecret, map[string]any{"exp": exp, "iss": "work3", "aud": "other"}, true, true},
+		{"no exp", testSecret, map[string]any{"iss": "work3", "aud": "api"}, true, true},
+		{"no exp allowed", testSecret, map[string]any{"iss": "work3", "aud": "api"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			j, err := NewJWT(config.JWTConfig{
+				Secret: testSecret, Issuer: "work3", Audience: "api", RequireExp: tt.requireExp, Leeway: 30 * time.Second,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = j.parse(signHS256(t, tt.secret, tt.claims), now)
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidToken)) {
+				t.Errorf("parse() = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTRejectsUnsignedTokens(t *testing.T) {
+	t.Parallel()
+
+	j, err := NewJWT(config.JWTConfig{Secret: testSecret, RequireExp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","exp":9999999999}`))
+
+	if _, err := j.parse(header+"."+payload+".", time.Now()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("alg none accepted: %v", err)
+	}
+}
+
+func TestJWTScopes(t *testing.T) {
+	t.Parallel()
+
+	j, err := NewJWT(config.JWTConfig{Secret: testSecret, ScopeClaim: "scope"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := j.scopes(map[string]any{"scope": "read admin"}); !slices.Equal(got, []string{"read", "admin"}) {
+		t.Errorf("scopes = %q", got)
+	}
+}
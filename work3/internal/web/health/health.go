@@ -0,0 +1,151 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+const checkTimeout = 2 * time.Second
+
+var ErrNotReady = errors.New("server is not ready")
+
+// Check reports a dependency problem with a non-nil error.
+type Check func(ctx context.Context) error
+
+// Health serves liveness and readiness probes. Readiness fails while the server
+// is not accepting traffic (e.g. draining) or when any registered check fails.
+type Health struct {
+	ready func() bool
+
+	mu     sync.RWMutex
+	names  []string
+	checks map[string]Check
+}
+
+type report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type buildInfo struct {
+	Path      string `json:"path"`
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified"`
+}
+
+func New(ready func() bool) *Health {
+	return &Health{ready: ready, checks: make(map[string]Check)}
+}
+
+// Register adds a named readiness check; registering a name again replaces the check.
+func (h *Health) Register(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.checks[name]; !ok {
+		h.names = append(h.names, name)
+	}
+
+	h.checks[name] = check
+}
+
+// Healthz reports that the process is alive.
+func (h *Health) Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, report{Status: "ok"})
+}
+
+// Readyz runs the checks concurrently and reports 503 if any of them fails.
+func (h *Health) Readyz(w http.ResponseWriter, req *http.Request) {
+	// copied under the lock, Register may run concurrently
+	h.mu.RLock()
+	names := slices.Clone(h.names)
+	checks := make([]Check, len(names))
+
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+	defer cancel()
+
+	results := make([]error, len(names))
+
+	var wg sync.WaitGroup
+
+	for i := range checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[i] = checks[i](ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	res := report{Status: "ok", Checks: make(map[string]string, len(names)+1)}
+
+	if !h.ready() {
+		res.Status = "fail"
+		res.Checks["server"] = ErrNotReady.Error()
+	}
+
+	for i, name := range names {
+		res.Checks[name] = "ok"
+
+		if results[i] != nil {
+			res.Status = "fail"
+			res.Checks[name] = results[i].Error()
+		}
+	}
+
+	status := http.StatusOK
+	if res.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, res)
+}
+
+// Version returns build information of the binary.
+func Version(w http.ResponseWriter, _ *http.Request) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		http.Error(w, "build info is not available", http.StatusNotFound)
+
+		return
+	}
+
+	info := buildInfo{Path: bi.Main.Path, Version: bi.Main.Version, GoVersion: bi.GoVersion}
+
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func readyz(t *testing.T, h *Health) (int, report) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var res report
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+
+	return rec.Code, res
+}
+
+func TestReadyz(t *testing.T) {
+	t.Parallel()
+
+	ready := true
+	h := New(func() bool { return ready })
+	h.Register("db", func(context.Context) error { return nil })
+
+	if code, res := readyz(t, h); code != http.StatusOK || res.Checks["db"] != "ok" {
+		t.Fatalf("ready: %d %+v", code, res)
+	}
+
+	h.Register("cache", func(context.Context) error { return errors.New("connection refused") })
+
+	if code, res := readyz(t, h); code != http.StatusServiceUnavailable || res.Checks["cache"] != "connection refused" {
+		t.Fatalf("failing check: %d %+v", code, res)
+	}
+
+	h.Register("cache", func(context.Context) error { return nil })
+	ready = false
+
+	if code, res := readyz(t, h); code != http.StatusServiceUnavailable || res.Checks["server"] != ErrNotReady.Error() {
+		t.Fatalf("draining: %d %+v", code, res)
+	}
+}
+
+func TestReadyzConcurrentRegister(t *testing.T) {
+	t.Parallel()
+
+	h := New(func() bool { return true })
+
+	var wg sync.WaitGroup
+
+	for i := range 50 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			h.Register(fmt.Sprintf("check-%d", i), func(context.Context) error { return nil })
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			h.Readyz(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// AccessLog writes a structured log record per request.
+type AccessLog struct {
+	Logger *slog.Logger
+}
+
+func (a AccessLog) HandlerFunc(h http.Handler) http.Handler {
+	return StatusHandler{ProcessingFunc: a.log}.HandlerFunc(h)
+}
+
+func (a AccessLog) log(info RequestInfo) {
+	attributes := []any{
+		slog.String("request_id", RequestIDFromContext(info.Request.Context())),
+		slog.String("method", info.Method),
+		slog.String("path", info.Path),
+		slog.Int("status", info.StatusCode),
+		slog.Duration("duration", info.Duration),
+		slog.Duration("ttfb", info.TimeToFirstByte),
+		slog.Int64("bytes", info.Bytes),
+		slog.String("remote_ip", remoteIP(info.Request)),
+		slog.String("user_agent", info.Request.UserAgent()),
+	}
+
+	//nolint:mnd
+	if info.StatusCode >= 400 {
+		a.Logger.Error("request processed", attributes...)
+	} else {
+		a.Logger.Info("request processed", attributes...)
+	}
+}
+
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
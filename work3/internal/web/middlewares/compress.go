@@ -0,0 +1,252 @@
+package middlewares
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// Compress encodes responses with brotli, gzip or deflate as negotiated by
+// Accept-Encoding. Responses shorter than the minimum size, of other content
+// types or already encoded are passed through.
+type Compress struct {
+	level        int
+	minSize      int
+	encodings    []string
+	contentTypes []string
+}
+
+func NewCompress(conf config.CompressionConfig) *Compress {
+	encodings := []string{"gzip", "deflate"}
+	if conf.Brotli {
+		encodings = append([]string{"br"}, encodings...)
+	}
+
+	return &Compress{
+		level:        conf.Level,
+		minSize:      conf.MinSize,
+		encodings:    encodings,
+		contentTypes: conf.ContentTypes,
+	}
+}
+
+func (c *Compress) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiate(req.Header.Get("Accept-Encoding"), c.encodings)
+		if encoding == "" || req.Method == http.MethodHead {
+			h.ServeHTTP(w, req)
+
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, compress: c, encoding: encoding, status: http.StatusOK}
+
+		h.ServeHTTP(cw, req)
+
+		// not deferred: on panic the buffered response is dropped, so Recovery can still answer 500
+		_ = cw.Close()
+	})
+}
+
+func (c *Compress) compressible(header http.Header) bool {
+	contentType := header.Get("Content-Type")
+
+	for _, prefix := range c.contentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Compress) encoder(encoding string, w io.Writer) io.WriteCloser {
+	switch encoding {
+	case "br":
+		return brotli.NewWriterLevel(w, c.level)
+	case "deflate":
+		fw, _ := flate.NewWriter(w, c.level)
+
+		return fw
+	default:
+		gw, _ := gzip.NewWriterLevel(w, c.level)
+
+		return gw
+	}
+}
+
+// compressWriter buffers the response until the minimum size is reached and
+// then decides whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	compress *Compress
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.decided || statusCode < http.StatusOK {
+		w.ResponseWriter.WriteHeader(statusCode)
+
+		return
+	}
+
+	w.status = statusCode
+	if !bodyAllowed(statusCode) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		if _, ok := w.Header()["Content-Type"]; !ok {
+			w.Header().Set("Content-Type", http.DetectContentType(append(w.buf, data...)))
+		}
+
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.compress.minSize {
+			return len(data), nil
+		}
+
+		return len(data), w.decide(true)
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(data) //nolint:wrapcheck
+	}
+
+	return w.ResponseWriter.Write(data) //nolint:wrapcheck
+}
+
+// Flush commits to compression so streaming responses are not held in the buffer.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("hijack: %w", http.ErrNotSupported)
+	}
+
+	w.decided = true
+
+	return hijacker.Hijack() //nolint:wrapcheck
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close writes out a short buffered response or finishes the compressed stream.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		return w.decide(false)
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Close() //nolint:wrapcheck
+	}
+
+	return nil
+}
+
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	header := w.Header()
+	compress = compress && bodyAllowed(w.status) && header.Get("Content-Encoding") == "" &&
+		header.Get("Content-Range") == "" && w.compress.compressible(header)
+
+	if compress {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		w.encoder = w.compress.encoder(w.encoding, w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+
+	w.buf = nil
+
+	return err //nolint:wrapcheck
+}
+
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// negotiate picks the supported encoding with the highest q-value; ties keep server preference.
+func negotiate(header string, supported []string) string {
+	weights := make(map[string]float64)
+
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		weights[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := "", 0.0
+
+	for _, encoding := range supported {
+		q, ok := weights[encoding]
+		if !ok {
+			q, ok = weights["*"]
+		}
+
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,128 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+func newTestCompress() *Compress {
+	return NewCompress(config.CompressionConfig{
+		Enabled:      true,
+		Level:        5,
+		MinSize:      16,
+		Brotli:       true,
+		ContentTypes: []string{"text/"},
+	})
+}
+
+func TestCompressNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"deflate, gzip;q=0", "deflate"},
+		{"*", "br"},
+		{"identity", ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiate(tt.header, []string{"br", "gzip", "deflate"}); got != tt.want {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompressResponse(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("hello compression ", 10)
+	h := newTestCompress().HandlerFunc(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != body {
+		t.Errorf("body = %q, want %q", data, body)
+	}
+}
+
+func TestCompressShortResponse(t *testing.T) {
+	t.Parallel()
+
+	h := newTestCompress().HandlerFunc(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, "short")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+
+	if rec.Body.String() != "short" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "short")
+	}
+}
+
+func TestCompressPanic(t *testing.T) {
+	t.Parallel()
+
+	recovery := Recovery{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	h := recovery.HandlerFunc(newTestCompress().HandlerFunc(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "partial")
+
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+}
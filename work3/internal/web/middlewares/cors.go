@@ -0,0 +1,146 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// CORS answers preflight requests and adds Access-Control-* headers for allowed origins.
+type CORS struct {
+	origins     []string
+	anyOrigin   bool
+	methods     []string
+	headers     []string
+	anyHeader   bool
+	exposed     string
+	credentials bool
+	maxAge      string
+}
+
+func NewCORS(conf config.CORSConfig) *CORS {
+	headers := make([]string, 0, len(conf.AllowedHeaders))
+	for _, header := range conf.AllowedHeaders {
+		headers = append(headers, http.CanonicalHeaderKey(header))
+	}
+
+	return &CORS{
+		origins:     conf.AllowedOrigins,
+		anyOrigin:   slices.Contains(conf.AllowedOrigins, "*"),
+		methods:     conf.AllowedMethods,
+		headers:     headers,
+		anyHeader:   slices.Contains(conf.AllowedHeaders, "*"),
+		exposed:     strings.Join(conf.ExposedHeaders, ", "),
+		credentials: conf.AllowCredentials,
+		maxAge:      strconv.Itoa(int(conf.MaxAge.Seconds())),
+	}
+}
+
+func (c *CORS) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		if origin == "" {
+			h.ServeHTTP(w, req)
+
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !c.allowOrigin(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			h.ServeHTTP(w, req)
+
+			return
+		}
+
+		c.setOrigin(w.Header(), origin)
+
+		if preflight {
+			c.preflight(w, req)
+
+			return
+		}
+
+		if c.exposed != "" {
+			w.Header().Set("Access-Control-Expose-Headers", c.exposed)
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+func (c *CORS) preflight(w http.ResponseWriter, req *http.Request) {
+	method := req.Header.Get("Access-Control-Request-Method")
+	if !slices.Contains(c.methods, method) {
+		w.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	requested := req.Header.Get("Access-Control-Request-Headers")
+	for header := range strings.SplitSeq(requested, ",") {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header != "" && !c.anyHeader && !slices.Contains(c.headers, header) {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+
+	if requested != "" {
+		w.Header().Set("Access-Control-Allow-Headers", requested)
+	}
+
+	w.Header().Set("Access-Control-Max-Age", c.maxAge)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CORS) setOrigin(header http.Header, origin string) {
+	if c.anyOrigin && !c.credentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowOrigin matches exact origins and wildcard subdomain patterns like https://*.example.com.
+func (c *CORS) allowOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	for _, allowed := range c.origins {
+		if allowed == origin {
+			return true
+		}
+
+		prefix, suffix, found := strings.Cut(allowed, "*")
+		if found && len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,158 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// Faults injects latency, error statuses and aborted connections into requests
+// matching the configured rules. Rules can be listed and toggled at runtime.
+// Requests to the exempt routes are never faulted.
+type Faults struct {
+	exempt []string
+
+	mu    sync.RWMutex
+	rules []config.FaultRule
+}
+
+func NewFaults(conf config.FaultConfig, exempt ...string) *Faults {
+	rules := make([]config.FaultRule, len(conf.Rules))
+	copy(rules, conf.Rules)
+
+	return &Faults{exempt: exempt, rules: rules}
+}
+
+func (f *Faults) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if isExempt(req, f.exempt) {
+			h.ServeHTTP(w, req)
+
+			return
+		}
+
+		rule, ok := f.match(req)
+		if !ok {
+			h.ServeHTTP(w, req)
+
+			return
+		}
+
+		if rule.Delay > 0 {
+			timer := time.NewTimer(rule.Delay)
+
+			select {
+			case <-req.Context().Done():
+				timer.Stop()
+
+				return
+			case <-timer.C:
+			}
+		}
+
+		if rule.Abort {
+			panic(http.ErrAbortHandler)
+		}
+
+		status := rule.Status
+		if rule.StatusFromQuery != "" {
+			status, _ = strconv.Atoi(req.URL.Query().Get(rule.StatusFromQuery))
+		}
+
+		if status == 0 || http.StatusText(status) == "" {
+			h.ServeHTTP(w, req)
+
+			return
+		}
+
+		w.Header().Set("X-Fault-Rule", rule.Name)
+		w.WriteHeader(status)
+	})
+}
+
+// List writes the rules as json.
+func (f *Faults) List(w http.ResponseWriter, _ *http.Request) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(f.rules)
+}
+
+// Toggle enables or disables the rule from the {name} path value with ?enabled=true|false.
+func (f *Faults) Toggle(w http.ResponseWriter, req *http.Request) {
+	enabled, err := strconv.ParseBool(req.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.rules {
+		if f.rules[i].Name == req.PathValue("name") {
+			f.rules[i].Enabled = enabled
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(f.rules[i])
+
+			return
+		}
+	}
+
+	http.Error(w, "rule not found", http.StatusNotFound)
+}
+
+// match returns the first enabled rule matching the request that passes its probability roll.
+func (f *Faults) match(req *http.Request) (config.FaultRule, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, rule := range f.rules {
+		if !rule.Enabled || !matchRule(rule, req) {
+			continue
+		}
+
+		if rule.Probability == 0 || rand.Float64() < rule.Probability { //nolint:gosec
+			return rule, true
+		}
+	}
+
+	return config.FaultRule{}, false
+}
+
+func matchRule(rule config.FaultRule, req *http.Request) bool {
+	if rule.Method != "" && rule.Method != req.Method {
+		return false
+	}
+
+	if rule.Route != "" && rule.Route != RouteFromContext(req.Context()) {
+		return false
+	}
+
+	if rule.StatusFromQuery != "" && !req.URL.Query().Has(rule.StatusFromQuery) {
+		return false
+	}
+
+	for name, value := range rule.Headers {
+		if actual := req.Header.Get(name); actual == "" || (value != "" && value != actual) {
+			return false
+		}
+	}
+
+	query := req.URL.Query()
+	for name, value := range rule.Query {
+		if !query.Has(name) || (value != "" && value != query.Get(name)) {
+			return false
+		}
+	}
+
+	return true
+}
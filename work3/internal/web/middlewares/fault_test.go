@@ -0,0 +1,90 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// serveRoute serves the request as if the router matched the route.
+func serveRoute(h http.Handler, route string, req *http.Request) *httptest.ResponseRecorder {
+	req = WithRoute(req)
+	SetRoute(req.Context(), route)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestFaults(t *testing.T) {
+	t.Parallel()
+
+	faults := NewFaults(config.FaultConfig{Rules: []config.FaultRule{
+		{Name: "query", Enabled: true, StatusFromQuery: "code"},
+		{Name: "users", Enabled: true, Method: http.MethodPost, Route: "/users", Status: http.StatusServiceUnavailable},
+		{Name: "disabled", Enabled: false, Status: http.StatusTeapot},
+	}}, "/healthz")
+
+	h := faults.HandlerFunc(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		method   string
+		route    string
+		target   string
+		wantCode int
+		wantRule string
+	}{
+		{"no match", http.MethodGet, "/users", "/users", http.StatusOK, ""},
+		{"status from query", http.MethodGet, "/users", "/users?code=502", http.StatusBadGateway, "query"},
+		{"invalid status from query", http.MethodGet, "/users", "/users?code=abc", http.StatusOK, ""},
+		{"method and route", http.MethodPost, "/users", "/users", http.StatusServiceUnavailable, "users"},
+		{"other route", http.MethodPost, "/orders", "/orders", http.StatusOK, ""},
+		{"exempt route", http.MethodGet, "/healthz", "/healthz?code=500", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveRoute(h, tt.route, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rec.Code != tt.wantCode || rec.Header().Get("X-Fault-Rule") != tt.wantRule {
+				t.Errorf("got %d %q, want %d %q", rec.Code, rec.Header().Get("X-Fault-Rule"), tt.wantCode, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestFaultsToggle(t *testing.T) {
+	t.Parallel()
+
+	faults := NewFaults(config.FaultConfig{Rules: []config.FaultRule{
+		{Name: "teapot", Status: http.StatusTeapot},
+	}})
+
+	h := faults.HandlerFunc(http.NotFoundHandler())
+
+	if rec := serveRoute(h, "/", httptest.NewRequest(http.MethodGet, "/", nil)); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled rule fired: %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/faults/teapot?enabled=true", nil)
+	req.SetPathValue("name", "teapot")
+
+	rec := httptest.NewRecorder()
+	faults.Toggle(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("toggle: %d", rec.Code)
+	}
+
+	if rec := serveRoute(h, "/", httptest.NewRequest(http.MethodGet, "/", nil)); rec.Code != http.StatusTeapot {
+		t.Fatalf("enabled rule did not fire: %d", rec.Code)
+	}
+}
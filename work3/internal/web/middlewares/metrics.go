@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/mch735/education/work3/internal/metrics"
+)
+
+const unmatchedRoute = "unmatched"
+
+// Metrics records request counters, latency histograms and in-flight gauges.
+// Routes are labeled with the registered router pattern to keep cardinality bounded.
+type Metrics struct {
+	requests *metrics.Counter
+	bytes    *metrics.Counter
+	duration *metrics.Histogram
+	inFlight *metrics.Gauge
+}
+
+func NewMetrics(registry *metrics.Registry) *Metrics {
+	return &Metrics{
+		requests: registry.Counter("http_requests_total", "Total number of processed HTTP requests.", "method", "route", "status"),
+		bytes:    registry.Counter("http_response_size_bytes_total", "Total size of HTTP responses.", "method", "route"),
+		duration: registry.Histogram(
+			"http_request_duration_seconds", "HTTP request latency.", metrics.DefaultBuckets, "method", "route",
+		),
+		inFlight: registry.Gauge("http_requests_in_flight", "Number of HTTP requests being processed."),
+	}
+}
+
+func (m *Metrics) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		req = WithRoute(req)
+		sw := NewStatusWriter(w)
+		completed := false
+
+		// deferred so that requests whose panic goes through are counted as well
+		defer func() {
+			info := sw.Info(req)
+			method := methodLabel(req.Method)
+
+			route := RouteFromContext(req.Context())
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			if !completed && !sw.Written() {
+				info.StatusCode = http.StatusInternalServerError
+			}
+
+			m.requests.Inc(method, route, strconv.Itoa(info.StatusCode))
+			m.bytes.Add(float64(info.Bytes), method, route)
+			m.duration.Observe(info.Duration.Seconds(), method, route)
+		}()
+
+		h.ServeHTTP(sw.Writer(), req)
+
+		completed = true
+	})
+}
+
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+
+	return "other"
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mch735/education/work3/internal/metrics"
+)
+
+func TestMetricsPanics(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	recovery := Recovery{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	h := NewMetrics(registry).HandlerFunc(recovery.HandlerFunc(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/abort" {
+			panic(http.ErrAbortHandler)
+		}
+
+		panic("boom")
+	})))
+
+	if rec := serveRoute(h, "/panic", httptest.NewRequest(http.MethodGet, "/panic", nil)); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("code = %d", rec.Code)
+	}
+
+	func() {
+		defer func() { _ = recover() }()
+
+		serveRoute(h, "/abort", httptest.NewRequest(http.MethodGet, "/abort", nil))
+	}()
+
+	var out strings.Builder
+	if _, err := registry.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/panic",status="500"} 1`,
+		`http_requests_total{method="GET",route="/abort",status="500"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/abort"} 1`,
+		`http_requests_in_flight 0`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %s in\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package middlewares
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// RateLimit is a token bucket limiter keyed by client ip, header value or route;
+// requests without the header are keyed by client ip. Clients of unix sockets
+// have no ip and are keyed by connection, see ConnContext. Responses carry
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers; rejected
+// requests get 429 with Retry-After. Requests to the exempt routes are not limited.
+type RateLimit struct {
+	rate        float64
+	burst       float64
+	idleTimeout time.Duration
+	key         func(req *http.Request) string
+	exempt      []string
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimit(conf config.RateLimitConfig, exempt ...string) *RateLimit {
+	r := &RateLimit{
+		rate:        conf.Rate,
+		burst:       float64(conf.Burst),
+		idleTimeout: conf.IdleTimeout,
+		exempt:      exempt,
+		buckets:     make(map[string]*bucket),
+		swept:       time.Now(),
+	}
+
+	switch conf.Key {
+	case config.RateLimitKeyHeader:
+		header := conf.Header
+		r.key = func(req *http.Request) string {
+			// prefixed so a header value never shares a bucket with an ip
+			if value := req.Header.Get(header); value != "" {
+				return "header:" + value
+			}
+
+			return "ip:" + clientKey(req)
+		}
+	case config.RateLimitKeyRoute:
+		r.key = func(req *http.Request) string { return req.Method + " " + RouteFromContext(req.Context()) }
+	case config.RateLimitKeyIP:
+		r.key = clientKey
+	}
+
+	return r
+}
+
+type connKey struct{}
+
+var connections atomic.Uint64
+
+// ConnContext tags the context of every connection with a unique id, to be set
+// as http.Server.ConnContext.
+func ConnContext(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, connections.Add(1))
+}
+
+// clientKey returns the client ip or, when there is none as for unix sockets
+// ("" or "@"), the connection id so local clients do not share one bucket.
+func clientKey(req *http.Request) string {
+	ip := remoteIP(req)
+	if ip != "" && ip != "@" {
+		return ip
+	}
+
+	if id, ok := req.Context().Value(connKey{}).(uint64); ok {
+		return "conn:" + strconv.FormatUint(id, 10)
+	}
+
+	return ip
+}
+
+func (r *RateLimit) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if isExempt(req, r.exempt) {
+			h.ServeHTTP(w, req)
+
+			return
+		}
+
+		allowed, remaining, reset, retry := r.take(r.key(req), time.Now())
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(int(r.burst)))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(retry)))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+// take consumes a token and returns the tokens left, the time until the bucket
+// is full and, for rejected requests, the time until the next token.
+func (r *RateLimit) take(key string, now time.Time) (bool, int, time.Duration, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(now)
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[key] = b
+	}
+
+	b.tokens = math.Min(r.burst, b.tokens+now.Sub(b.last).Seconds()*r.rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	reset := r.duration(r.burst - b.tokens)
+	retry := time.Duration(0)
+
+	if !allowed {
+		retry = r.duration(1 - b.tokens)
+	}
+
+	return allowed, int(b.tokens), reset, retry
+}
+
+// sweep drops idle buckets at most once per idle timeout.
+func (r *RateLimit) sweep(now time.Time) {
+	if now.Sub(r.swept) < r.idleTimeout {
+		return
+	}
+
+	for key, b := range r.buckets {
+		if now.Sub(b.last) >= r.idleTimeout {
+			delete(r.buckets, key)
+		}
+	}
+
+	r.swept = now
+}
+
+func (r *RateLimit) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / r.rate * float64(time.Second))
+}
+
+// MaxInFlight rejects requests with 429 while the limit of concurrent requests
+// is reached. Requests to the exempt routes are neither counted nor rejected.
+type MaxInFlight struct {
+	slots  chan struct{}
+	exempt []string
+}
+
+func NewMaxInFlight(limit int, exempt ...string) *MaxInFlight {
+	return &MaxInFlight{slots: make(chan struct{}, limit), exempt: exempt}
+}
+
+func (m *MaxInFlight) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if isExempt(req, m.exempt) {
+			h.ServeHTTP(w, req)
+
+			return
+		}
+
+		select {
+		case m.slots <- struct{}{}:
+		default:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+
+			return
+		}
+
+		defer func() { <-m.slots }()
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,156 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+func newTestRateLimit(key config.RateLimitKey) *RateLimit {
+	return NewRateLimit(config.RateLimitConfig{
+		Rate: 1, Burst: 2, Key: key, Header: "X-Client", IdleTimeout: time.Minute,
+	}, "/healthz")
+}
+
+func TestRateLimitTake(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRateLimit(config.RateLimitKeyIP)
+	now := time.Now()
+
+	for i, want := range []bool{true, true, false} {
+		if allowed, _, _, _ := r.take("client", now); allowed != want {
+			t.Fatalf("request %d: allowed = %t", i, allowed)
+		}
+	}
+
+	allowed, remaining, _, retry := r.take("client", now)
+	if allowed || remaining != 0 || retry != time.Second {
+		t.Fatalf("rejected: remaining %d, retry %s", remaining, retry)
+	}
+
+	// a token is refilled per second
+	if allowed, _, _, _ := r.take("client", now.Add(time.Second)); !allowed {
+		t.Fatal("not refilled")
+	}
+
+	if allowed, _, _, _ := r.take("other", now); !allowed {
+		t.Fatal("buckets are shared between keys")
+	}
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	t.Parallel()
+
+	h := newTestRateLimit(config.RateLimitKeyHeader).HandlerFunc(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(route, remoteAddr, client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, route, nil)
+		req.RemoteAddr = remoteAddr
+
+		if client != "" {
+			req.Header.Set("X-Client", client)
+		}
+
+		return serveRoute(h, route, req)
+	}
+
+	for range 2 {
+		serve("/users", "10.0.0.1:1234", "")
+		serve("/users", "10.0.0.9:1234", "alice")
+	}
+
+	// requests without the header are limited per client ip
+	if rec := serve("/users", "10.0.0.1:1234", ""); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("ip bucket: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	if rec := serve("/users", "10.0.0.2:1234", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("other ip: %d", rec.Code)
+	}
+
+	if rec := serve("/users", "10.0.0.2:1234", "alice"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("header bucket: %d", rec.Code)
+	}
+
+	for range 5 {
+		if rec := serve("/healthz", "10.0.0.1:1234", ""); rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("exempt route limited: %d", rec.Code)
+		}
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	m := NewMaxInFlight(1, "/healthz")
+	h := m.HandlerFunc(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		serveRoute(h, "/slow", httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+
+	<-started
+
+	if rec := serveRoute(h, "/users", httptest.NewRequest(http.MethodGet, "/users", nil)); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("over limit: %d", rec.Code)
+	}
+
+	if rec := serveRoute(h, "/healthz", httptest.NewRequest(http.MethodGet, "/healthz", nil)); rec.Code != http.StatusNoContent {
+		t.Errorf("exempt route: %d", rec.Code)
+	}
+
+	close(release)
+	<-done
+}
+
+func TestRateLimitUnixSocketClients(t *testing.T) {
+	t.Parallel()
+
+	h := newTestRateLimit(config.RateLimitKeyIP).HandlerFunc(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	first := ConnContext(context.Background(), nil)
+	second := ConnContext(context.Background(), nil)
+
+	serve := func(ctx context.Context) int {
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		req.RemoteAddr = "@"
+
+		return serveRoute(h, "/", req).Code
+	}
+
+	for range 2 {
+		serve(first)
+	}
+
+	if code := serve(first); code != http.StatusTooManyRequests {
+		t.Fatalf("connection over the limit: %d", code)
+	}
+
+	// another connection of the same socket has its own bucket
+	if code := serve(second); code != http.StatusNoContent {
+		t.Fatalf("other connection: %d", code)
+	}
+}
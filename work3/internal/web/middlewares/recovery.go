@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery turns handler panics into 500 responses and logs the stack.
+type Recovery struct {
+	Logger *slog.Logger
+}
+
+func (r Recovery) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		sw := NewStatusWriter(w)
+
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+
+			if err, ok := value.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(value)
+			}
+
+			r.Logger.Error("panic recovered",
+				slog.String("request_id", RequestIDFromContext(req.Context())),
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("panic", fmt.Sprint(value)),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			if !sw.Written() {
+				http.Error(sw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+
+		h.ServeHTTP(sw.Writer(), req)
+	})
+}
@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	RequestIDHeader = "X-Request-Id"
+
+	requestIDMaxLength = 128
+)
+
+type requestIDKey struct{}
+
+// RequestID propagates the request id from the header or generates a new one,
+// storing it in the request context and echoing it in the response header.
+type RequestID struct {
+	Header string
+}
+
+func (r RequestID) HandlerFunc(h http.Handler) http.Handler {
+	header := r.Header
+	if header == "" {
+		header = RequestIDHeader
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(header)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(header, id)
+
+		h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext returns the request id stored by RequestID or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+func newRequestID() string {
+	id := make([]byte, 16) //nolint:mnd
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > requestIDMaxLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+type routeKey struct{}
+
+// routeHolder is filled by the router once the request is matched, so wrappers
+// running before routing can read the pattern after the handler returns.
+type routeHolder struct {
+	pattern string
+}
+
+// WithRoute prepares the request to carry the matched route pattern.
+func WithRoute(req *http.Request) *http.Request {
+	if _, ok := req.Context().Value(routeKey{}).(*routeHolder); ok {
+		return req
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), routeKey{}, &routeHolder{}))
+}
+
+// SetRoute records the matched route path pattern.
+func SetRoute(ctx context.Context, pattern string) {
+	if holder, ok := ctx.Value(routeKey{}).(*routeHolder); ok {
+		holder.pattern = pattern
+	}
+}
+
+// RouteFromContext returns the registered pattern of the matched route or an
+// empty string when no route matched.
+func RouteFromContext(ctx context.Context) string {
+	if holder, ok := ctx.Value(routeKey{}).(*routeHolder); ok {
+		return holder.pattern
+	}
+
+	return ""
+}
+
+// isExempt reports whether the request matched one of the route path patterns.
+func isExempt(req *http.Request, routes []string) bool {
+	return slices.Contains(routes, RouteFromContext(req.Context()))
+}
@@ -1,29 +1,293 @@
 package middlewares
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"time"
 )
 
+// StatusHandler reports every processed request to ProcessingFunc.
 type StatusHandler struct {
-	ProcessingFunc func(method string, path string, statusCode int, statusText string)
+	ProcessingFunc func(info RequestInfo)
+}
+
+// RequestInfo describes a processed request.
+type RequestInfo struct {
+	Request         *http.Request
+	Method          string
+	Path            string
+	StatusCode      int
+	StatusText      string
+	Bytes           int64
+	Duration        time.Duration
+	TimeToFirstByte time.Duration
 }
 
 func (f StatusHandler) HandlerFunc(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		sw := StatusWriter{w, http.StatusOK}
+		sw := NewStatusWriter(w)
 
-		h.ServeHTTP(&sw, req)
+		h.ServeHTTP(sw.Writer(), req)
 
-		f.ProcessingFunc(req.Method, req.URL.Path, sw.StatusCode, http.StatusText(sw.StatusCode))
+		f.ProcessingFunc(sw.Info(req))
 	})
 }
 
+// StatusWriter records the status code, response size and timings. Handlers get
+// it through Writer, which keeps http.Flusher, http.Hijacker, io.ReaderFrom and
+// http.Pusher only when the wrapped writer has them; http.ResponseController
+// is supported through Unwrap.
 type StatusWriter struct {
 	http.ResponseWriter
 	StatusCode int
+	Bytes      int64
+
+	start       time.Time
+	firstByte   time.Time
+	wroteHeader bool
 }
 
+func NewStatusWriter(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w, StatusCode: http.StatusOK, start: time.Now()}
+}
+
+// WriteHeader records the first final status code; superfluous calls are ignored.
 func (w *StatusWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+
+	if statusCode >= http.StatusContinue && statusCode < http.StatusOK {
+		w.ResponseWriter.WriteHeader(statusCode)
+
+		return
+	}
+
 	w.StatusCode = statusCode
+	w.markWritten()
 	w.ResponseWriter.WriteHeader(statusCode)
 }
+
+func (w *StatusWriter) Write(data []byte) (int, error) {
+	w.markWritten()
+
+	n, err := w.ResponseWriter.Write(data)
+	w.Bytes += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
+
+// Writer returns the writer to pass to the handler, with the optional
+// interfaces of the wrapped writer.
+func (w *StatusWriter) Writer() http.ResponseWriter {
+	const (
+		flush = 1 << iota
+		hijack
+		readFrom
+		push
+	)
+
+	features := 0
+
+	if _, ok := w.ResponseWriter.(http.Flusher); ok {
+		features |= flush
+	}
+
+	if _, ok := w.ResponseWriter.(http.Hijacker); ok {
+		features |= hijack
+	}
+
+	if _, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		features |= readFrom
+	}
+
+	if _, ok := w.ResponseWriter.(http.Pusher); ok {
+		features |= push
+	}
+
+	f, h, r, p := statusFlusher{w}, statusHijacker{w}, statusReaderFrom{w}, statusPusher{w}
+
+	switch features {
+	case flush:
+		return struct {
+			*StatusWriter
+			http.Flusher
+		}{w, f}
+	case hijack:
+		return struct {
+			*StatusWriter
+			http.Hijacker
+		}{w, h}
+	case readFrom:
+		return struct {
+			*StatusWriter
+			io.ReaderFrom
+		}{w, r}
+	case push:
+		return struct {
+			*StatusWriter
+			http.Pusher
+		}{w, p}
+	case flush | hijack:
+		return struct {
+			*StatusWriter
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case flush | readFrom:
+		return struct {
+			*StatusWriter
+			http.Flusher
+			io.ReaderFrom
+		}{w, f, r}
+	case flush | push:
+		return struct {
+			*StatusWriter
+			http.Flusher
+			http.Pusher
+		}{w, f, p}
+	case hijack | readFrom:
+		return struct {
+			*StatusWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{w, h, r}
+	case hijack | push:
+		return struct {
+			*StatusWriter
+			http.Hijacker
+			http.Pusher
+		}{w, h, p}
+	case readFrom | push:
+		return struct {
+			*StatusWriter
+			io.ReaderFrom
+			http.Pusher
+		}{w, r, p}
+	case flush | hijack | readFrom:
+		return struct {
+			*StatusWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{w, f, h, r}
+	case flush | hijack | push:
+		return struct {
+			*StatusWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, f, h, p}
+	case flush | readFrom | push:
+		return struct {
+			*StatusWriter
+			http.Flusher
+			io.ReaderFrom
+			http.Pusher
+		}{w, f, r, p}
+	case hijack | readFrom | push:
+		return struct {
+			*StatusWriter
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{w, h, r, p}
+	case flush | hijack | readFrom | push:
+		return struct {
+			*StatusWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{w, f, h, r, p}
+	}
+
+	return w
+}
+
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Written reports whether the response header has already been sent.
+func (w *StatusWriter) Written() bool {
+	return w.wroteHeader
+}
+
+// Info summarizes the response written so far.
+func (w *StatusWriter) Info(req *http.Request) RequestInfo {
+	info := RequestInfo{
+		Request:    req,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		StatusCode: w.StatusCode,
+		StatusText: http.StatusText(w.StatusCode),
+		Bytes:      w.Bytes,
+		Duration:   time.Since(w.start),
+	}
+
+	if !w.firstByte.IsZero() {
+		info.TimeToFirstByte = w.firstByte.Sub(w.start)
+	}
+
+	return info
+}
+
+func (w *StatusWriter) markWritten() {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.firstByte = time.Now()
+}
+
+type statusFlusher struct {
+	*StatusWriter
+}
+
+func (w statusFlusher) Flush() {
+	w.markWritten()
+	w.ResponseWriter.(http.Flusher).Flush() //nolint:forcetypeassert
+}
+
+type statusHijacker struct {
+	*StatusWriter
+}
+
+// Hijack records 101 Switching Protocols once the connection is taken over.
+func (w statusHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack() //nolint:forcetypeassert
+	if err != nil {
+		return nil, nil, fmt.Errorf("hijack: %w", err)
+	}
+
+	w.wroteHeader = true
+	w.StatusCode = http.StatusSwitchingProtocols
+
+	return conn, rw, nil
+}
+
+type statusReaderFrom struct {
+	*StatusWriter
+}
+
+func (w statusReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	w.markWritten()
+
+	n, err := w.ResponseWriter.(io.ReaderFrom).ReadFrom(src) //nolint:forcetypeassert
+	w.Bytes += n
+
+	return n, err //nolint:wrapcheck
+}
+
+type statusPusher struct {
+	*StatusWriter
+}
+
+func (w statusPusher) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts) //nolint:forcetypeassert,wrapcheck
+}
@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// hijackRecorder is a writer that can be hijacked, unlike httptest.ResponseRecorder.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	err error
+}
+
+func (w hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.err != nil {
+		return nil, nil, w.err
+	}
+
+	server, client := net.Pipe()
+	_ = client.Close()
+
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (w *pushRecorder) Push(target string, _ *http.PushOptions) error {
+	w.pushed = append(w.pushed, target)
+
+	return nil
+}
+
+func TestStatusWriterInterfaces(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                                  string
+		w                                     http.ResponseWriter
+		flusher, hijacker, readerFrom, pusher bool
+	}{
+		{"recorder", httptest.NewRecorder(), true, false, false, false},
+		{"writer only", struct{ http.ResponseWriter }{httptest.NewRecorder()}, false, false, false, false},
+		{"hijacker", hijackRecorder{ResponseRecorder: httptest.NewRecorder()}, true, true, false, false},
+		{"pusher", &pushRecorder{ResponseRecorder: httptest.NewRecorder()}, true, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := NewStatusWriter(tt.w).Writer()
+
+			_, flusher := w.(http.Flusher)
+			_, hijacker := w.(http.Hijacker)
+			_, readerFrom := w.(io.ReaderFrom)
+			_, pusher := w.(http.Pusher)
+
+			if flusher != tt.flusher || hijacker != tt.hijacker || readerFrom != tt.readerFrom || pusher != tt.pusher {
+				t.Errorf("flusher %t, hijacker %t, reader from %t, pusher %t", flusher, hijacker, readerFrom, pusher)
+			}
+		})
+	}
+}
+
+func TestStatusWriterRecords(t *testing.T) {
+	t.Parallel()
+
+	sw := NewStatusWriter(httptest.NewRecorder())
+	w := sw.Writer()
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = io.WriteString(w, "hello")
+	w.(http.Flusher).Flush()
+
+	info := sw.Info(httptest.NewRequest(http.MethodPost, "/users", nil))
+	if info.StatusCode != http.StatusCreated || info.Bytes != 5 || !sw.Written() {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestStatusWriterHijack(t *testing.T) {
+	t.Parallel()
+
+	failed := NewStatusWriter(hijackRecorder{ResponseRecorder: httptest.NewRecorder(), err: http.ErrHijacked})
+
+	if _, _, err := failed.Writer().(http.Hijacker).Hijack(); !errors.Is(err, http.ErrHijacked) {
+		t.Fatalf("hijack error = %v", err)
+	}
+
+	if failed.Written() || failed.StatusCode != http.StatusOK {
+		t.Errorf("failed hijack recorded: %d", failed.StatusCode)
+	}
+
+	sw := NewStatusWriter(hijackRecorder{ResponseRecorder: httptest.NewRecorder()})
+
+	conn, _, err := sw.Writer().(http.Hijacker).Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if !sw.Written() || sw.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("status after hijack = %d", sw.StatusCode)
+	}
+}
+
+func TestStatusWriterPush(t *testing.T) {
+	t.Parallel()
+
+	rec := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	if err := NewStatusWriter(rec).Writer().(http.Pusher).Push("/app.css", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(rec.pushed, ",") != "/app.css" {
+		t.Errorf("pushed = %q", rec.pushed)
+	}
+}
@@ -1,33 +1,310 @@
 package router
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/mch735/education/work3/internal/web/middlewares"
 )
 
+// Router registers routes and middleware chains on top of http.ServeMux.
+//
+// Patterns are registered on the mux right away, so invalid or conflicting ones
+// are reported by Err before serving. Chains are resolved when the first
+// request is served, so the order of Middleware and route registration calls
+// does not matter. Within a chain the
+// first registered wrapper is the outermost: router middlewares run first, then
+// group middlewares from the outer group inwards, then route middlewares.
+// Router middlewares also wrap requests that match no route.
+//
+// Routes must be registered before the first request: later registrations are
+// rejected and reported by Err.
 type Router struct {
+	root Group
+
+	once    sync.Once
+	mux     http.ServeMux
+	handler http.Handler
+
+	mu      sync.Mutex // guards the fields below against requests served concurrently with registration
+	serving bool
+	routes  []*route
+	paths   map[string]string
+	err     error
+}
+
+// Group is a set of routes sharing a path prefix and middlewares.
+type Group struct {
+	router      *Router
+	parent      *Group
+	prefix      string
 	middlewares []middlewares.Wrapper
-	http.ServeMux
 }
 
+// Route describes a registered route for debugging.
+type Route struct {
+	Method      string
+	Pattern     string
+	Middlewares []string
+}
+
+type route struct {
+	group       *Group
+	method      string
+	path        string
+	handler     http.Handler
+	middlewares []middlewares.Wrapper
+
+	wrapped http.Handler
+}
+
+var (
+	ErrInvalidRoute = errors.New("invalid route")
+	ErrServing      = errors.New("route registered after the router started serving")
+)
+
 func NewRouter() *Router {
-	return &Router{
-		make([]middlewares.Wrapper, 0),
-		http.ServeMux{},
+	r := &Router{paths: make(map[string]string)}
+	r.root.router = r
+
+	return r
+}
+
+// Middleware appends router-wide middlewares.
+func (r *Router) Middleware(wrappers ...middlewares.Wrapper) {
+	r.root.Middleware(wrappers...)
+}
+
+// Group creates a route group with the prefix and middlewares.
+func (r *Router) Group(prefix string, wrappers ...middlewares.Wrapper) *Group {
+	return r.root.Group(prefix, wrappers...)
+}
+
+func (r *Router) Handle(pattern string, handler http.Handler, wrappers ...middlewares.Wrapper) {
+	r.root.Handle(pattern, handler, wrappers...)
+}
+
+func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	r.root.HandleFunc(pattern, handler, wrappers...)
+}
+
+func (r *Router) Get(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	r.root.Get(pattern, handler, wrappers...)
+}
+
+func (r *Router) Post(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	r.root.Post(pattern, handler, wrappers...)
+}
+
+func (r *Router) Put(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	r.root.Put(pattern, handler, wrappers...)
+}
+
+func (r *Router) Patch(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	r.root.Patch(pattern, handler, wrappers...)
+}
+
+func (r *Router) Delete(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	r.root.Delete(pattern, handler, wrappers...)
+}
+
+// Routes lists registered routes with their full middleware chains, outermost first.
+func (r *Router) Routes() []Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	routes := make([]Route, 0, len(r.routes))
+
+	for _, rt := range r.routes {
+		names := make([]string, 0)
+		for _, wrapper := range rt.chain() {
+			names = append(names, fmt.Sprintf("%T", wrapper))
+		}
+
+		routes = append(routes, Route{Method: rt.method, Pattern: rt.path, Middlewares: names})
+	}
+
+	return routes
+}
+
+// Err returns the errors of the routes that could not be registered.
+func (r *Router) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+// ServeHTTP resolves the route before running the middlewares, so every wrapper
+// can read its pattern with middlewares.RouteFromContext.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.once.Do(r.build)
+
+	req = middlewares.WithRoute(req)
+	if _, pattern := r.mux.Handler(req); pattern != "" {
+		middlewares.SetRoute(req.Context(), r.paths[pattern])
+	}
+
+	r.handler.ServeHTTP(w, req)
+}
+
+func (r *Router) build() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.serving = true
+
+	for _, rt := range r.routes {
+		rt.wrapped = wrap(rt.handler, rt.chain()[len(r.root.middlewares):])
+	}
+
+	r.handler = wrap(&r.mux, r.root.middlewares)
+}
+
+func (r *Router) add(rt *route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.serving {
+		r.err = errors.Join(r.err, fmt.Errorf("%w: %q", ErrServing, rt.pattern()))
+
+		return
+	}
+
+	if err := r.register(rt); err != nil {
+		r.err = errors.Join(r.err, err)
+
+		return
+	}
+
+	r.paths[rt.pattern()] = rt.path
+	r.routes = append(r.routes, rt)
+}
+
+// register adds the pattern to the mux, turning its panics on invalid or
+// conflicting patterns into errors.
+func (r *Router) register(rt *route) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = fmt.Errorf("%w: %q: %v", ErrInvalidRoute, rt.pattern(), value)
+		}
+	}()
+
+	r.mux.Handle(rt.pattern(), rt)
+
+	return nil
+}
+
+// Middleware appends group middlewares; they apply to all routes of the group and its subgroups.
+func (g *Group) Middleware(wrappers ...middlewares.Wrapper) {
+	g.middlewares = append(g.middlewares, wrappers...)
+}
+
+// Group creates a subgroup; its prefix is appended to the parent prefix.
+func (g *Group) Group(prefix string, wrappers ...middlewares.Wrapper) *Group {
+	return &Group{
+		router:      g.router,
+		parent:      g,
+		prefix:      join(g.prefix, prefix),
+		middlewares: wrappers,
+	}
+}
+
+// Handle registers a handler; the pattern may start with a method as in http.ServeMux ("GET /users").
+func (g *Group) Handle(pattern string, handler http.Handler, wrappers ...middlewares.Wrapper) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+
+	g.handle(method, strings.TrimSpace(path), handler, wrappers)
+}
+
+func (g *Group) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	g.Handle(pattern, http.HandlerFunc(handler), wrappers...)
+}
+
+func (g *Group) Get(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	g.handle(http.MethodGet, pattern, http.HandlerFunc(handler), wrappers)
+}
+
+func (g *Group) Post(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	g.handle(http.MethodPost, pattern, http.HandlerFunc(handler), wrappers)
+}
+
+func (g *Group) Put(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	g.handle(http.MethodPut, pattern, http.HandlerFunc(handler), wrappers)
+}
+
+func (g *Group) Patch(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	g.handle(http.MethodPatch, pattern, http.HandlerFunc(handler), wrappers)
+}
+
+func (g *Group) Delete(pattern string, handler func(http.ResponseWriter, *http.Request), wrappers ...middlewares.Wrapper) {
+	g.handle(http.MethodDelete, pattern, http.HandlerFunc(handler), wrappers)
+}
+
+func (g *Group) handle(method, path string, handler http.Handler, wrappers []middlewares.Wrapper) {
+	g.router.add(&route{
+		group:       g,
+		method:      method,
+		path:        join(g.prefix, path),
+		handler:     handler,
+		middlewares: wrappers,
+	})
+}
+
+func (rt *route) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rt.wrapped.ServeHTTP(w, req)
+}
+
+// chain returns the route middlewares, outermost first.
+func (rt *route) chain() []middlewares.Wrapper {
+	groups := make([]*Group, 0)
+	for g := rt.group; g != nil; g = g.parent {
+		groups = append(groups, g)
+	}
+
+	chain := make([]middlewares.Wrapper, 0)
+	for i := len(groups) - 1; i >= 0; i-- {
+		chain = append(chain, groups[i].middlewares...)
 	}
+
+	return append(chain, rt.middlewares...)
 }
 
-func (r *Router) Middleware(wrapper middlewares.Wrapper) {
-	r.middlewares = append(r.middlewares, wrapper)
+func (rt *route) pattern() string {
+	if rt.method == "" {
+		return rt.path
+	}
+
+	return rt.method + " " + rt.path
 }
 
-func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
-	h := http.Handler(http.HandlerFunc(handler))
+func (rt Route) String() string {
+	method := rt.Method
+	if method == "" {
+		method = "*"
+	}
+
+	return fmt.Sprintf("%-7s %s [%s]", method, rt.Pattern, strings.Join(rt.Middlewares, " -> "))
+}
+
+func wrap(h http.Handler, wrappers []middlewares.Wrapper) http.Handler {
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		h = wrappers[i].HandlerFunc(h)
+	}
+
+	return h
+}
 
-	for _, wrapper := range r.middlewares {
-		h = wrapper.HandlerFunc(h)
+func join(prefix, path string) string {
+	if prefix == "" {
+		return path
 	}
 
-	r.Handle(pattern, h)
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
 }
@@ -0,0 +1,139 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mch735/education/work3/internal/web/middlewares"
+)
+
+// tag appends its name to the X-Chain response header.
+type tag string
+
+func (t tag) HandlerFunc(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("X-Chain", string(t))
+		h.ServeHTTP(w, req)
+	})
+}
+
+func echoRoute(w http.ResponseWriter, req *http.Request) {
+	_, _ = io.WriteString(w, middlewares.RouteFromContext(req.Context()))
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+
+	return rec
+}
+
+func TestRouterRegistrationErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		register func(r *Router)
+		wantErr  bool
+	}{
+		{"valid", func(r *Router) { r.Get("/a", echoRoute); r.Post("/a", echoRoute) }, false},
+		{"duplicate", func(r *Router) { r.Get("/a", echoRoute); r.Get("/a", echoRoute) }, true},
+		{"empty path", func(r *Router) { r.Handle("GET ", http.HandlerFunc(echoRoute)) }, true},
+		{"invalid wildcard", func(r *Router) { r.Get("/{a", echoRoute) }, true},
+		{"group duplicate", func(r *Router) { r.Get("/api/x", echoRoute); r.Group("/api").Get("/x", echoRoute) }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRouter()
+			tt.register(r)
+
+			err := r.Err()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Err() = %v, wantErr %t", err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidRoute) {
+				t.Errorf("Err() = %v, want ErrInvalidRoute", err)
+			}
+
+			// the router keeps serving the valid routes
+			if rec := serve(r, http.MethodGet, "/missing"); rec.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestRouterChains(t *testing.T) {
+	t.Parallel()
+
+	r := NewRouter()
+	api := r.Group("/api", tag("group"))
+	api.Get("/users/{id}", echoRoute, tag("route"))
+	r.Get("/plain", echoRoute)
+	// registered after the routes, still outermost
+	r.Middleware(tag("router"))
+
+	tests := []struct {
+		target    string
+		wantCode  int
+		wantChain string
+		wantBody  string
+	}{
+		{"/api/users/1", http.StatusOK, "router,group,route", "/api/users/{id}"},
+		{"/plain", http.StatusOK, "router", "/plain"},
+		{"/nope", http.StatusNotFound, "router", ""},
+	}
+
+	for _, tt := range tests {
+		rec := serve(r, http.MethodGet, tt.target)
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.target, rec.Code, tt.wantCode)
+		}
+
+		if chain := strings.Join(rec.Header().Values("X-Chain"), ","); chain != tt.wantChain {
+			t.Errorf("%s: chain = %q, want %q", tt.target, chain, tt.wantChain)
+		}
+
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("%s: body = %q, want %q", tt.target, rec.Body.String(), tt.wantBody)
+		}
+	}
+}
+
+func TestRouterRegisterAfterServing(t *testing.T) {
+	t.Parallel()
+
+	r := NewRouter()
+	r.Get("/a", echoRoute)
+
+	// registration races with the first requests without corrupting the router
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		r.Get("/b", echoRoute)
+	}()
+
+	serve(r, http.MethodGet, "/a")
+	<-done
+
+	r.Get("/c", echoRoute)
+
+	if err := r.Err(); !errors.Is(err, ErrServing) || !strings.Contains(err.Error(), `"GET /c"`) {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	if rec := serve(r, http.MethodGet, "/c"); rec.Code != http.StatusNotFound {
+		t.Errorf("route registered after serving: %d", rec.Code)
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+var ErrNoSystemdListener = errors.New("no matching systemd socket")
+
+var systemdListeners = sync.OnceValues(inheritedListeners)
+
+func listen(conf config.ListenerConfig) ([]net.Listener, error) {
+	switch conf.Network { //nolint:exhaustive
+	case config.ListenerUnix:
+		listener, err := listenUnix(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{listener}, nil
+	case config.ListenerSystemd:
+		return listenSystemd(conf.Address)
+	default:
+		listener, err := net.Listen(string(conf.Network), conf.Address)
+		if err != nil {
+			return nil, fmt.Errorf("listen %s: %w", conf, err)
+		}
+
+		return []net.Listener{listener}, nil
+	}
+}
+
+// listenUnix removes a stale socket file left by a previous run and applies the configured mode.
+func listenUnix(conf config.ListenerConfig) (net.Listener, error) {
+	if info, err := os.Stat(conf.Address); err == nil && info.Mode().Type() == fs.ModeSocket {
+		if conn, err := net.Dial("unix", conf.Address); err == nil {
+			_ = conn.Close()
+
+			return nil, fmt.Errorf("listen %s: socket is in use", conf)
+		}
+
+		_ = os.Remove(conf.Address)
+	}
+
+	listener, err := net.Listen("unix", conf.Address)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", conf, err)
+	}
+
+	mode, _ := conf.FileMode()
+	if mode != 0 {
+		if err := os.Chmod(conf.Address, mode); err != nil {
+			_ = listener.Close()
+
+			return nil, fmt.Errorf("listen %s: %w", conf, err)
+		}
+	}
+
+	return listener, nil
+}
+
+// listenSystemd returns inherited sockets with the LISTEN_FDNAMES name or all of them for an empty name.
+func listenSystemd(name string) ([]net.Listener, error) {
+	inherited, err := systemdListeners()
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(inherited))
+
+	for _, listener := range inherited {
+		if name == "" || listener.name == name {
+			listeners = append(listeners, listener.Listener)
+		}
+	}
+
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNoSystemdListener, name)
+	}
+
+	return listeners, nil
+}
+
+type namedListener struct {
+	net.Listener
+	name string
+}
+
+// inheritedListeners reads LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES once and
+// unsets them so child processes don't inherit the sockets.
+func inheritedListeners() ([]namedListener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, fmt.Errorf("%w: LISTEN_PID is not set for this process", ErrNoSystemdListener)
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("%w: LISTEN_FDS is not set", ErrNoSystemdListener)
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make([]namedListener, 0, count)
+
+	for i := range count {
+		fd := listenFDsStart + i
+		file := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("systemd socket %d: %w", fd, err)
+		}
+
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+
+		listeners = append(listeners, namedListener{Listener: listener, name: name})
+	}
+
+	return listeners, nil
+}
@@ -1,22 +1,33 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/mch735/education/work3/internal/config"
-	"github.com/mch735/education/work3/internal/util"
+	"github.com/mch735/education/work3/internal/web/middlewares"
 	"github.com/mch735/education/work3/internal/web/router"
 )
 
-const (
-	ReadTimeout  time.Duration = 5 * time.Second
-	WriteTimeout time.Duration = 3 * time.Second
-)
+var ErrShutdownTimeout = errors.New("shutdown timeout exceeded")
 
 type Server struct {
 	http.Server
+
+	redirect  *http.Server
+	certs     *certificates
+	listeners []config.ListenerConfig
+
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
+
+	ready    atomic.Bool
+	inFlight atomic.Int64
 }
 
 func NewServer(conf config.ServerConfig) (*Server, error) {
@@ -24,24 +35,198 @@ func NewServer(conf config.ServerConfig) (*Server, error) {
 		return nil, fmt.Errorf("invalid server settings: %w", err)
 	}
 
-	addr := fmt.Sprintf("%s:%d", conf.Host, conf.Port)
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(conf.TLS.HTTP2)
 
-	return &Server{
-		http.Server{
-			Addr:         addr,
-			ReadTimeout:  ReadTimeout,
-			WriteTimeout: WriteTimeout,
+	server := &Server{
+		Server: http.Server{
+			ReadTimeout:  conf.ReadTimeout,
+			WriteTimeout: conf.WriteTimeout,
+			Protocols:    protocols,
+			ConnContext:  middlewares.ConnContext,
 		},
-	}, nil
+		listeners:       conf.Addresses(),
+		drainDelay:      conf.DrainDelay,
+		shutdownTimeout: conf.ShutdownTimeout,
+	}
+
+	if conf.TLS.Enabled {
+		tlsConfig, certs, err := newTLSConfig(conf.TLS, string(conf.Host))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls settings: %w", err)
+		}
+
+		server.Server.TLSConfig = tlsConfig
+		server.certs = certs
+
+		server.redirect = newRedirectServer(conf, server.listeners)
+	}
+
+	return server, nil
 }
 
 func (s *Server) Router(router *router.Router) {
-	s.Server.Handler = router
+	s.Server.Handler = s.track(router)
+}
+
+// Ready reports whether the server accepts new traffic; it turns false as soon as shutdown begins.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// InFlight returns the number of requests currently being processed.
+func (s *Server) InFlight() int64 {
+	return s.inFlight.Load()
+}
+
+// ReloadCertificates rereads the tls certificate files; it is a no-op for plain http
+// and self-signed certificates.
+func (s *Server) ReloadCertificates() error {
+	if s.certs == nil || s.certs.certFile == "" {
+		return nil
+	}
+
+	return s.certs.Reload()
+}
+
+// CheckCertificate is a readiness check failing when the tls certificate has
+// expired; it always passes for plain http.
+func (s *Server) CheckCertificate(ctx context.Context) error {
+	if s.certs == nil {
+		return nil
+	}
+
+	return s.certs.Check(ctx)
+}
+
+// Listeners returns the configured listener addresses.
+func (s *Server) Listeners() []string {
+	addresses := make([]string, 0, len(s.listeners))
+	for _, listener := range s.listeners {
+		addresses = append(addresses, listener.String())
+	}
+
+	return addresses
 }
 
-func (s *Server) Run() {
-	err := s.Server.ListenAndServe()
+// Run serves every listener until ctx is canceled, then flips readiness, waits
+// for the drain delay and drains in-flight requests within the shutdown timeout.
+func (s *Server) Run(ctx context.Context) error {
+	servers := []*http.Server{&s.Server}
+	if s.redirect != nil {
+		servers = append(servers, s.redirect)
+	}
+
+	listeners, err := s.listen()
 	if err != nil {
-		util.Fatal(err)
+		return err
+	}
+
+	errs := make(chan error, len(listeners))
+
+	for _, l := range listeners {
+		go func() {
+			if l.tls {
+				errs <- l.server.ServeTLS(l.listener, "", "")
+			} else {
+				errs <- l.server.Serve(l.listener)
+			}
+		}()
+	}
+
+	s.ready.Store(true)
+
+	select {
+	case err := <-errs:
+		s.ready.Store(false)
+		closeServers(servers)
+
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	return s.shutdown(servers, errs, len(listeners))
+}
+
+// serverListener decides on tls before serving: http.Server may fill TLSConfig
+// itself while configuring HTTP/2 on the first Serve call.
+type serverListener struct {
+	server   *http.Server
+	listener net.Listener
+	tls      bool
+}
+
+func (s *Server) listen() ([]serverListener, error) {
+	listeners := make([]serverListener, 0, len(s.listeners))
+
+	for _, conf := range s.listeners {
+		ls, err := listen(conf)
+		if err != nil {
+			closeAll(listeners)
+
+			return nil, fmt.Errorf("server listen: %w", err)
+		}
+
+		for _, l := range ls {
+			if conf.Redirect {
+				listeners = append(listeners, serverListener{server: s.redirect, listener: l})
+			} else {
+				listeners = append(listeners, serverListener{server: &s.Server, listener: l, tls: s.Server.TLSConfig != nil})
+			}
+		}
+	}
+
+	return listeners, nil
+}
+
+func (s *Server) shutdown(servers []*http.Server, errs <-chan error, serving int) error {
+	s.ready.Store(false)
+	time.Sleep(s.drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	for _, srv := range servers {
+		err := srv.Shutdown(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			inFlight := s.InFlight()
+			closeServers(servers)
+
+			return fmt.Errorf("%w: %d requests in flight", ErrShutdownTimeout, inFlight)
+		}
+
+		if err != nil {
+			return fmt.Errorf("server shutdown: %w", err)
+		}
+	}
+
+	for range serving {
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) track(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+func closeServers(servers []*http.Server) {
+	for _, srv := range servers {
+		_ = srv.Close()
+	}
+}
+
+func closeAll(listeners []serverListener) {
+	for _, l := range listeners {
+		_ = l.listener.Close()
 	}
 }
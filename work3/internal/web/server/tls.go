@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+const selfSignedTTL = 365 * 24 * time.Hour
+
+var (
+	ErrInvalidClientCA    = errors.New("no certificates in client ca file")
+	ErrCertificateExpired = errors.New("certificate expired")
+)
+
+// certificates keeps the serving certificate and reloads it when the files change.
+type certificates struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newTLSConfig(conf config.TLSConfig, host string) (*tls.Config, *certificates, error) {
+	certs := &certificates{certFile: conf.CertFile, keyFile: conf.KeyFile, interval: conf.ReloadInterval}
+
+	var err error
+
+	if conf.CertFile == "" {
+		certs.cert, err = selfSigned(host)
+	} else {
+		err = certs.Reload()
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tlsVersion(conf.MinVersion),
+		GetCertificate: certs.get,
+		ClientAuth:     clientAuth(conf),
+	}
+
+	if conf.ClientCAFile != "" {
+		pem, err := os.ReadFile(conf.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("client ca: %w", err)
+		}
+
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, nil, ErrInvalidClientCA
+		}
+	}
+
+	return tlsConfig, certs, nil
+}
+
+// Reload reads the certificate and key files again.
+func (c *certificates) Reload() error {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return fmt.Errorf("certificate: %w", err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("certificate: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.modTime = info.ModTime()
+	c.checked = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *certificates) get(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	cert, modTime, checked := c.cert, c.modTime, c.checked
+	c.mu.RUnlock()
+
+	if c.certFile == "" || time.Since(checked) < c.interval {
+		return cert, nil
+	}
+
+	c.mu.Lock()
+	c.checked = time.Now()
+	c.mu.Unlock()
+
+	info, err := os.Stat(c.certFile)
+	if err != nil || !info.ModTime().After(modTime) {
+		return cert, nil //nolint:nilerr // keep serving the loaded certificate
+	}
+
+	if err := c.Reload(); err != nil {
+		return cert, nil //nolint:nilerr // a half-written pair must not break handshakes
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cert, nil
+}
+
+// Check fails when the serving certificate has expired.
+func (c *certificates) Check(_ context.Context) error {
+	c.mu.RLock()
+	cert := c.cert
+	c.mu.RUnlock()
+
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("certificate: %w", err)
+		}
+	}
+
+	if time.Now().After(leaf.NotAfter) {
+		return fmt.Errorf("%w on %s", ErrCertificateExpired, leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func selfSigned(host string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("self-signed key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)) //nolint:mnd
+	if err != nil {
+		return nil, fmt.Errorf("self-signed serial: %w", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(selfSignedTTL),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}, //nolint:mnd
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+		template.IPAddresses = append(template.IPAddresses, ip)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("self-signed certificate: %w", err)
+	}
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// newRedirectServer serves the redirect listeners, sending clients to the port
+// of the first tcp https listener; it returns nil when there are no redirect listeners.
+func newRedirectServer(conf config.ServerConfig, listeners []config.ListenerConfig) *http.Server {
+	if !slices.ContainsFunc(listeners, func(l config.ListenerConfig) bool { return l.Redirect }) {
+		return nil
+	}
+
+	port := httpsPort(listeners)
+
+	return &http.Server{
+		ReadTimeout:  conf.ReadTimeout,
+		WriteTimeout: conf.WriteTimeout,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			host, _, err := net.SplitHostPort(req.Host)
+			if err != nil {
+				host = req.Host
+			}
+
+			if port != "" {
+				host = net.JoinHostPort(host, port)
+			}
+
+			http.Redirect(w, req, "https://"+host+req.URL.RequestURI(), http.StatusPermanentRedirect)
+		}),
+	}
+}
+
+// httpsPort returns the port of the first tcp https listener or an empty string
+// for the default port when it is unknown, as for unix and systemd sockets.
+func httpsPort(listeners []config.ListenerConfig) string {
+	for _, l := range listeners {
+		switch l.Network { //nolint:exhaustive
+		case config.ListenerTCP, config.ListenerTCP4, config.ListenerTCP6:
+			if l.Redirect {
+				continue
+			}
+
+			if _, port, err := net.SplitHostPort(l.Address); err == nil && port != "443" && port != "0" {
+				return port
+			}
+
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func tlsVersion(version config.TLSVersion) uint16 {
+	if version == config.TLSVersion13 {
+		return tls.VersionTLS13
+	}
+
+	return tls.VersionTLS12
+}
+
+func clientAuth(conf config.TLSConfig) tls.ClientAuthType {
+	return map[config.ClientAuth]tls.ClientAuthType{
+		config.ClientAuthNone:    tls.NoClientCert,
+		config.ClientAuthRequest: tls.VerifyClientCertIfGiven,
+		config.ClientAuthRequire: tls.RequireAndVerifyClientCert,
+	}[conf.ClientAuth]
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+)
+
+func TestRedirectServer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		listeners []config.ListenerConfig
+		want      string
+	}{
+		{
+			"tls listener port",
+			[]config.ListenerConfig{
+				{Network: config.ListenerTCP, Address: ":8080", Redirect: true},
+				{Network: config.ListenerUnix, Address: "/tmp/app.sock"},
+				{Network: config.ListenerTCP4, Address: "127.0.0.1:8443"},
+			},
+			"https://example.com:8443/users?id=1",
+		},
+		{
+			"default port",
+			[]config.ListenerConfig{
+				{Network: config.ListenerTCP, Address: ":80", Redirect: true},
+				{Network: config.ListenerTCP, Address: ":443"},
+			},
+			"https://example.com/users?id=1",
+		},
+		{
+			"unknown port",
+			[]config.ListenerConfig{
+				{Network: config.ListenerTCP, Address: ":80", Redirect: true},
+				{Network: config.ListenerSystemd, Address: "https"},
+			},
+			"https://example.com/users?id=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newRedirectServer(config.ServerConfig{}, tt.listeners)
+
+			rec := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com:8080/users?id=1", nil))
+
+			if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != tt.want {
+				t.Errorf("got %d %q, want %q", rec.Code, rec.Header().Get("Location"), tt.want)
+			}
+		})
+	}
+}
+
+func TestRedirectServerDisabled(t *testing.T) {
+	t.Parallel()
+
+	conf := config.ServerConfig{Host: "127.0.0.1", Port: 9443}
+
+	if srv := newRedirectServer(conf, conf.Addresses()); srv != nil {
+		t.Error("redirect server without redirect listeners")
+	}
+
+	conf.TLS = config.TLSConfig{Enabled: true, RedirectPort: 9080}
+
+	listeners := conf.Addresses()
+	if len(listeners) != 2 || listeners[1].Address != "127.0.0.1:9080" || !listeners[1].Redirect {
+		t.Fatalf("listeners = %v", listeners)
+	}
+
+	if srv := newRedirectServer(conf, listeners); srv == nil {
+		t.Error("no redirect server for the redirect port")
+	}
+}
+
+func TestCertificatesCheck(t *testing.T) {
+	t.Parallel()
+
+	cert, err := selfSigned("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&certificates{cert: cert}).Check(context.Background()); err != nil {
+		t.Fatalf("self-signed certificate: %v", err)
+	}
+
+	expired := &tls.Certificate{Leaf: &x509.Certificate{NotAfter: time.Now().Add(-time.Hour)}}
+
+	if err := (&certificates{cert: expired}).Check(context.Background()); !errors.Is(err, ErrCertificateExpired) {
+		t.Fatalf("expired certificate: %v", err)
+	}
+}
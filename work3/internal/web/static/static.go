@@ -0,0 +1,263 @@
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mch735/education/work3/internal/config"
+	"github.com/mch735/education/work3/internal/web/router"
+)
+
+// encodings are precompressed variants in preference order.
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Handler serves files from a directory or an embedded file system with
+// ETag/Last-Modified validation, precompressed .br/.gz variants, optional
+// directory listing and SPA fallback to the index file.
+type Handler struct {
+	fsys    fs.FS
+	prefix  string
+	index   string
+	listing bool
+	spa     bool
+	maxAge  time.Duration
+
+	etags sync.Map
+}
+
+type etagKey struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func New(fsys fs.FS, conf config.StaticConfig) *Handler {
+	return &Handler{
+		fsys:    fsys,
+		prefix:  conf.Prefix,
+		index:   conf.Index,
+		listing: conf.Listing,
+		spa:     conf.SPA,
+		maxAge:  conf.MaxAge,
+	}
+}
+
+// Register mounts the handler on the router under the configured prefix; the
+// prefix without the trailing slash redirects to it.
+func (h *Handler) Register(r *router.Router) {
+	r.Handle(http.MethodGet+" "+h.prefix+"{path...}", h)
+
+	if h.prefix != "/" {
+		r.Handle(http.MethodGet+" "+strings.TrimSuffix(h.prefix, "/"), http.RedirectHandler(h.prefix, http.StatusMovedPermanently))
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(req.URL.Path, h.prefix)), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+
+	switch {
+	case err == nil && info.IsDir():
+		h.serveDir(w, req, name)
+	case err == nil:
+		h.serveFile(w, req, name, info)
+	case errors.Is(err, fs.ErrNotExist) && h.spa && path.Ext(name) == "":
+		h.serveIndex(w, req, ".")
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (h *Handler) serveDir(w http.ResponseWriter, req *http.Request, dir string) {
+	if !strings.HasSuffix(req.URL.Path, "/") {
+		http.Redirect(w, req, req.URL.Path+"/", http.StatusMovedPermanently)
+
+		return
+	}
+
+	if _, err := fs.Stat(h.fsys, path.Join(dir, h.index)); err == nil {
+		h.serveIndex(w, req, dir)
+
+		return
+	}
+
+	if !h.listing {
+		http.NotFound(w, req)
+
+		return
+	}
+
+	entries, err := fs.ReadDir(h.fsys, dir)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<!doctype html>\n<pre>\n")
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", (&url.URL{Path: name}).String(), html.EscapeString(name))
+	}
+
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+func (h *Handler) serveIndex(w http.ResponseWriter, req *http.Request, dir string) {
+	name := path.Join(dir, h.index)
+
+	info, err := fs.Stat(h.fsys, name)
+	if err != nil {
+		http.NotFound(w, req)
+
+		return
+	}
+
+	h.serveFile(w, req, name, info)
+}
+
+// serveFile serves the file or its precompressed variant; http.ServeContent
+// handles conditional and range requests.
+func (h *Handler) serveFile(w http.ResponseWriter, req *http.Request, name string, info fs.FileInfo) {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	if path.Base(name) == h.index {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	served, servedInfo := name, info
+
+	for _, enc := range encodings {
+		if !accepts(req, enc.name) {
+			continue
+		}
+
+		if variant, err := fs.Stat(h.fsys, name+enc.ext); err == nil && !variant.IsDir() {
+			served, servedInfo = name+enc.ext, variant
+			w.Header().Set("Content-Encoding", enc.name)
+
+			break
+		}
+	}
+
+	content, err := h.open(served)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+	defer content.Close()
+
+	etag, err := h.etag(served, servedInfo)
+	if err == nil {
+		w.Header().Set("ETag", etag)
+	}
+
+	http.ServeContent(w, req, "", servedInfo.ModTime(), content)
+}
+
+// etag is a content hash, so it stays stable for embedded files without modification times.
+func (h *Handler) etag(name string, info fs.FileInfo) (string, error) {
+	key := etagKey{name: name, size: info.Size(), modTime: info.ModTime()}
+
+	if etag, ok := h.etags.Load(key); ok {
+		return etag.(string), nil //nolint:forcetypeassert
+	}
+
+	file, err := h.fsys.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("etag: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("etag: %w", err)
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	h.etags.Store(key, etag)
+
+	return etag, nil
+}
+
+type readSeekCloser interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+func (h *Handler) open(name string) (readSeekCloser, error) {
+	file, err := h.fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	if rs, ok := file.(readSeekCloser); ok {
+		return rs, nil
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func accepts(req *http.Request, encoding string) bool {
+	for part := range strings.SplitSeq(req.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(name) == encoding && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mch735/education/work3/internal/config"
+	"github.com/mch735/education/work3/internal/web/router"
+)
+
+func TestRegisterPrefixes(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"index.html":  {Data: []byte("<html>index</html>")},
+		"app.js":      {Data: []byte("console.log(1)")},
+		"docs/a.html": {Data: []byte("<html>a</html>")},
+	}
+
+	tests := []struct {
+		prefix   string
+		target   string
+		wantCode int
+	}{
+		{"/", "/", http.StatusOK},
+		{"/", "/app.js", http.StatusOK},
+		{"/", "/docs/a.html", http.StatusOK},
+		{"/", "/missing.js", http.StatusNotFound},
+		{"/app/", "/app/app.js", http.StatusOK},
+		{"/app/", "/app", http.StatusMovedPermanently},
+		{"/app/", "/app/users", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		r := router.NewRouter()
+		New(fsys, config.StaticConfig{Enabled: true, Prefix: tt.prefix, Index: "index.html", SPA: true}).Register(r)
+
+		if err := r.Err(); err != nil {
+			t.Fatalf("prefix %q: %v", tt.prefix, err)
+		}
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("prefix %q, GET %s: status = %d, want %d", tt.prefix, tt.target, rec.Code, tt.wantCode)
+		}
+	}
+}
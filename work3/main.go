@@ -1,18 +1,31 @@
 package main
 
 import (
+	"context"
+	"embed"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/mch735/education/work3/internal/config"
 	"github.com/mch735/education/work3/internal/logger"
+	"github.com/mch735/education/work3/internal/metrics"
 	"github.com/mch735/education/work3/internal/util"
+	"github.com/mch735/education/work3/internal/web/auth"
+	"github.com/mch735/education/work3/internal/web/health"
 	"github.com/mch735/education/work3/internal/web/middlewares"
 	"github.com/mch735/education/work3/internal/web/router"
 	"github.com/mch735/education/work3/internal/web/server"
+	"github.com/mch735/education/work3/internal/web/static"
 )
 
+//go:embed web
+var webFS embed.FS
+
 func home(w http.ResponseWriter, _ *http.Request) {
 	fmt.Fprintf(w, "Hello =)")
 }
@@ -23,36 +36,167 @@ func main() {
 		util.Fatal(err)
 	}
 
+	if conf.PrintOnly() {
+		if err := conf.Report(os.Stdout); err != nil {
+			util.Fatal(err)
+		}
+
+		return
+	}
+
 	logger, err := logger.NewLogger(conf.LoggerConfig)
 	if err != nil {
 		util.Fatal(err)
 	}
 
+	app, err := server.NewServer(conf.ServerConfig)
+	if err != nil {
+		util.Fatal(err)
+	}
+
+	probes := health.New(app.Ready)
+
+	if conf.ServerConfig.TLS.Enabled {
+		probes.Register("tls_certificate", app.CheckCertificate)
+	}
+
+	registry := metrics.NewRegistry()
+	registry.RegisterRuntime()
+
+	// probes, metrics and admin endpoints are exempt from rate limiting and faults
+	internal := []string{"/healthz", "/readyz", "/metrics"}
+
+	if conf.LoggerConfig.AdminPath != "" {
+		internal = append(internal, conf.LoggerConfig.AdminPath)
+	}
+
+	if conf.FaultConfig.AdminPath != "" {
+		internal = append(internal, conf.FaultConfig.AdminPath, conf.FaultConfig.AdminPath+"/{name}")
+	}
+
 	router := router.NewRouter()
-	router.Middleware(middlewares.ResultHandler{})
-	router.Middleware(middlewares.StatusHandler{
-		ProcessingFunc: func(method, path string, statusCode int, statusText string) {
-			attributes := []any{
-				slog.String("method", method),
-				slog.String("path", path),
-				slog.String("status", fmt.Sprintf("%d %s", statusCode, statusText)),
-			}
+	router.Middleware(
+		middlewares.RequestID{},
+		middlewares.NewMetrics(registry),
+		middlewares.AccessLog{Logger: logger.Logger},
+		middlewares.Recovery{Logger: logger.Logger},
+	)
+
+	if conf.CORSConfig.Enabled {
+		router.Middleware(middlewares.NewCORS(conf.CORSConfig))
+	}
+
+	if conf.CompressionConfig.Enabled {
+		router.Middleware(middlewares.NewCompress(conf.CompressionConfig))
+	}
+
+	if conf.RateLimitConfig.MaxInFlight > 0 {
+		router.Middleware(middlewares.NewMaxInFlight(conf.RateLimitConfig.MaxInFlight, internal...))
+	}
+
+	if conf.RateLimitConfig.Enabled {
+		router.Middleware(middlewares.NewRateLimit(conf.RateLimitConfig, internal...))
+	}
+
+	// admin routes require the admin scope once authentication is enabled
+	admin := make([]middlewares.Wrapper, 0)
+
+	if conf.AuthConfig.Enabled {
+		authenticator, err := auth.New(conf.AuthConfig)
+		if err != nil {
+			util.Fatal(err)
+		}
+
+		router.Middleware(authenticator)
+
+		admin = append(admin, auth.RequireScopes("admin"))
+	}
+
+	if conf.FaultConfig.Enabled {
+		faults := middlewares.NewFaults(conf.FaultConfig, internal...)
+
+		router.Middleware(faults)
+
+		if conf.FaultConfig.AdminPath != "" {
+			router.Get(conf.FaultConfig.AdminPath, faults.List, admin...)
+			router.Post(conf.FaultConfig.AdminPath+"/{name}", faults.Toggle, admin...)
+		}
+	}
 
-			//nolint:mnd
-			if statusCode >= 400 {
-				logger.Error("request processed", attributes...)
-			} else {
-				logger.Info("request processed", attributes...)
-			}
-		},
-	})
 	router.HandleFunc("/", home)
+	router.Handle("GET /metrics", registry.Handler())
+	router.Get("/healthz", probes.Healthz)
+	router.Get("/readyz", probes.Readyz)
+	router.Get("/version", health.Version)
 
-	app, err := server.NewServer(conf.ServerConfig)
-	if err != nil {
+	if conf.LoggerConfig.AdminPath != "" {
+		router.HandleFunc(conf.LoggerConfig.AdminPath, logger.LevelHandler, admin...)
+	}
+
+	if conf.StaticConfig.Enabled {
+		static.New(staticFS(conf.StaticConfig), conf.StaticConfig).Register(router)
+	}
+
+	if err := router.Err(); err != nil {
 		util.Fatal(err)
 	}
 
+	for _, route := range router.Routes() {
+		logger.Debug("route registered", slog.String("route", route.String()))
+	}
+
 	app.Router(router)
-	app.Run()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	go reloadOnHangup(ctx, app, logger.Logger)
+
+	logger.Info("server started", slog.Any("listeners", app.Listeners()))
+
+	err = app.Run(ctx)
+
+	stop()
+
+	if err != nil {
+		_ = logger.Close()
+
+		util.Fatal(err)
+	}
+
+	logger.Info("server stopped")
+
+	_ = logger.Close()
+}
+
+func staticFS(conf config.StaticConfig) fs.FS {
+	if conf.Dir != "" {
+		return os.DirFS(conf.Dir)
+	}
+
+	files, err := fs.Sub(webFS, "web")
+	if err != nil {
+		util.Fatal(err)
+	}
+
+	return files
+}
+
+func reloadOnHangup(ctx context.Context, app *server.Server, logger *slog.Logger) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			if err := app.ReloadCertificates(); err != nil {
+				logger.Error("certificate reload failed", slog.String("err", err.Error()))
+			} else {
+				logger.Info("certificates reloaded")
+			}
+		}
+	}
 }
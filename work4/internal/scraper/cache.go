@@ -0,0 +1,263 @@
+package scraper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cacheMaxBody = 10 << 20
+
+// cachedHeaders are the response headers kept with a cached body.
+var cachedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Cache-Control", "Expires", "Date"}
+
+// Cache is an on-disk HTTP cache keyed by URL. Successful responses are stored
+// with their validators and freshness headers; stale entries are revalidated
+// with conditional requests.
+type Cache struct {
+	dir string
+}
+
+// CacheEntry is a cached response; the body is kept in a separate file.
+type CacheEntry struct {
+	URL        string      `json:"url"`
+	FinalURL   string      `json:"final_url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	StoredAt   time.Time   `json:"stored_at"`
+
+	body []byte
+}
+
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd
+		return nil, fmt.Errorf("cache dir: %w", err)
+	}
+
+	return &Cache{dir: dir}, nil
+}
+
+// Load returns the entry of the URL, nil if there is none or it can't be read.
+func (c *Cache) Load(rawURL string) *CacheEntry {
+	base := c.path(rawURL)
+
+	data, err := os.ReadFile(base + ".json")
+	if err != nil {
+		return nil
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != rawURL {
+		return nil
+	}
+
+	if entry.body, err = os.ReadFile(base + ".body"); err != nil {
+		return nil
+	}
+
+	return &entry
+}
+
+// Store caches a cacheable response and returns it with the body replaced by
+// the stored copy; other responses are returned untouched.
+func (c *Cache) Store(rawURL string, res *http.Response) (*http.Response, error) {
+	if !cacheable(res) {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, cacheMaxBody+1))
+	if err != nil {
+		res.Body.Close()
+
+		return nil, fmt.Errorf("response read error: %w", err)
+	}
+
+	if len(body) > cacheMaxBody {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+
+		return res, nil
+	}
+
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := &CacheEntry{
+		URL:        rawURL,
+		FinalURL:   res.Request.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     make(http.Header),
+		body:       body,
+	}
+	entry.update(res.Header)
+
+	return res, c.save(entry)
+}
+
+// Revalidated refreshes the entry from a 304 response and stores it again.
+func (c *Cache) Revalidated(entry *CacheEntry, res *http.Response) error {
+	entry.update(res.Header)
+
+	return c.save(entry)
+}
+
+// save writes the body before the metadata, so an entry is only visible once complete.
+func (c *Cache) save(entry *CacheEntry) error {
+	base := c.path(entry.URL)
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+
+	if err := writeFile(base+".body", entry.body); err != nil {
+		return err
+	}
+
+	return writeFile(base+".json", meta)
+}
+
+func (c *Cache) path(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	name := hex.EncodeToString(sum[:])
+
+	return filepath.Join(c.dir, name[:2], name)
+}
+
+// Fresh reports whether the entry can be used without revalidation.
+func (e *CacheEntry) Fresh(now time.Time) bool {
+	directives := cacheControl(e.Header.Get("Cache-Control"))
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+
+	if value, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(value)
+
+		return err == nil && now.Before(e.StoredAt.Add(time.Duration(seconds)*time.Second))
+	}
+
+	if expires, err := http.ParseTime(e.Header.Get("Expires")); err == nil {
+		return now.Before(expires)
+	}
+
+	return false
+}
+
+// Conditional returns the headers revalidating the entry.
+func (e *CacheEntry) Conditional() http.Header {
+	header := make(http.Header)
+
+	if etag := e.Header.Get("ETag"); etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+
+	if modified := e.Header.Get("Last-Modified"); modified != "" {
+		header.Set("If-Modified-Since", modified)
+	}
+
+	return header
+}
+
+// Response returns the cached response as if it was received from u, by
+// default from the final URL it was stored for.
+func (e *CacheEntry) Response(u *url.URL) *http.Response {
+	if u == nil {
+		var err error
+
+		if u, err = url.Parse(e.FinalURL); err != nil || e.FinalURL == "" {
+			u, _ = url.Parse(e.URL)
+		}
+	}
+
+	return &http.Response{
+		Status:     strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode),
+		StatusCode: e.StatusCode,
+		Header:     e.Header.Clone(),
+		Body:       io.NopCloser(bytes.NewReader(e.body)),
+		Request:    &http.Request{Method: http.MethodGet, URL: u},
+	}
+}
+
+func (e *CacheEntry) update(header http.Header) {
+	for _, name := range cachedHeaders {
+		if value := header.Get(name); value != "" {
+			e.Header.Set(name, value)
+		}
+	}
+
+	e.StoredAt = time.Now()
+}
+
+// cacheable accepts successful responses that allow storing and can be reused,
+// either while fresh or through revalidation.
+func cacheable(res *http.Response) bool {
+	if res.StatusCode != http.StatusOK {
+		return false
+	}
+
+	directives := cacheControl(res.Header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+
+	_, maxAge := directives["max-age"]
+
+	return maxAge || res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != "" || res.Header.Get("Expires") != ""
+}
+
+func cacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+
+	for part := range strings.SplitSeq(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+
+	return directives
+}
+
+// writeFile replaces the file atomically.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+		return fmt.Errorf("cache: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("cache: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("cache: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cacheResponse(rawURL string, header http.Header, body string) *http.Response {
+	u, _ := url.Parse(rawURL)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    &http.Request{Method: http.MethodGet, URL: u},
+	}
+}
+
+func TestCacheStoreLoad(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const rawURL = "https://example.com/page"
+
+	header := http.Header{"Etag": {`"v1"`}, "Cache-Control": {"max-age=60"}, "Set-Cookie": {"id=1"}}
+
+	res, err := cache.Store(rawURL, cacheResponse(rawURL+"?final", header, "<html>page</html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body, _ := io.ReadAll(res.Body); string(body) != "<html>page</html>" {
+		t.Fatalf("stored response body = %q", body)
+	}
+
+	entry := cache.Load(rawURL)
+	if entry == nil {
+		t.Fatal("entry not found")
+	}
+
+	if entry.Header.Get("Set-Cookie") != "" || entry.Header.Get("ETag") != `"v1"` {
+		t.Errorf("cached headers = %v", entry.Header)
+	}
+
+	if !entry.Fresh(time.Now()) || entry.Fresh(time.Now().Add(time.Minute)) {
+		t.Error("max-age freshness")
+	}
+
+	if got := entry.Conditional().Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("If-None-Match = %q", got)
+	}
+
+	cached := entry.Response(nil)
+	if body, _ := io.ReadAll(cached.Body); string(body) != "<html>page</html>" || cached.Request.URL.String() != rawURL+"?final" {
+		t.Errorf("cached response %q from %s", body, cached.Request.URL)
+	}
+
+	if cache.Load("https://example.com/other") != nil {
+		t.Error("entry for an unknown url")
+	}
+}
+
+func TestCacheRevalidated(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const rawURL = "https://example.com/"
+
+	header := http.Header{"Last-Modified": {"Thu, 01 Jan 2026 12:00:00 GMT"}}
+	if _, err := cache.Store(rawURL, cacheResponse(rawURL, header, "body")); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := cache.Load(rawURL)
+	if entry.Fresh(time.Now()) {
+		t.Fatal("entry without freshness headers is fresh")
+	}
+
+	notModified := &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{"Cache-Control": {"max-age=60"}}}
+	if err := cache.Revalidated(entry, notModified); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry = cache.Load(rawURL); !entry.Fresh(time.Now()) || entry.Header.Get("Last-Modified") == "" {
+		t.Errorf("revalidated entry = %v", entry.Header)
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status int
+		header http.Header
+		want   bool
+	}{
+		{http.StatusOK, http.Header{"Etag": {`"v1"`}}, true},
+		{http.StatusOK, http.Header{"Cache-Control": {"public, max-age=60"}}, true},
+		{http.StatusOK, http.Header{"Cache-Control": {"no-store"}, "Etag": {`"v1"`}}, false},
+		{http.StatusOK, http.Header{}, false},
+		{http.StatusNotFound, http.Header{"Etag": {`"v1"`}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := cacheable(&http.Response{StatusCode: tt.status, Header: tt.header}); got != tt.want {
+			t.Errorf("cacheable(%d, %v) = %t", tt.status, tt.header, got)
+		}
+	}
+}
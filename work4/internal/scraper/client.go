@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+var (
+	ErrTooManyRedirects = errors.New("too many redirects")
+	ErrInvalidCABundle  = errors.New("no certificates in ca bundle")
+)
+
+// NewClient builds the http client shared by all workers, so connections are
+// reused across them.
+func NewClient(conf *Config) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+
+	if conf.Proxy != "" {
+		proxyURL, err := url.Parse(conf.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: %w", err)
+		}
+
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: conf.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if conf.CAFile != "" {
+		pool, err := caPool(conf.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	dialer := &net.Dialer{Timeout: conf.Timeout, KeepAlive: conf.KeepAlive}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   conf.Timeout,
+		ForceAttemptHTTP2:     conf.HTTP2,
+		MaxIdleConns:          conf.MaxIdleConns,
+		MaxIdleConnsPerHost:   conf.MaxIdleConnsPerHost,
+		IdleConnTimeout:       conf.IdleConnTimeout,
+		ExpectContinueTimeout: conf.Timeout,
+	}
+
+	if !conf.HTTP2 {
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	return &http.Client{
+		Transport:     transport,
+		Timeout:       conf.Timeout,
+		CheckRedirect: redirectPolicy(conf.MaxRedirects),
+	}, nil
+}
+
+// redirectPolicy follows up to maxHops redirects; with zero the redirect response itself is returned.
+func redirectPolicy(maxHops int) func(req *http.Request, via []*http.Request) error {
+	return func(_ *http.Request, via []*http.Request) error {
+		if maxHops == 0 {
+			return http.ErrUseLastResponse
+		}
+
+		if len(via) > maxHops {
+			return fmt.Errorf("%w: more than %d", ErrTooManyRedirects, maxHops)
+		}
+
+		return nil
+	}
+}
+
+// caPool adds the certificates of the bundle to the system pool.
+func caPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ca bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCABundle, path)
+	}
+
+	return pool, nil
+}
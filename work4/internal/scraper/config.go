@@ -9,8 +9,60 @@ type Config struct {
 	ThreadCount int           `env:"THREAD_COUNT" env-default:"5"`
 	Timeout     time.Duration `env:"TIMEOUT"      env-default:"5s"`
 	RetryCount  int           `env:"RETRY_COUNT"  env-default:"3"`
+	Deadline    time.Duration `env:"DEADLINE"     env-default:"0s"`
+	RulesFile   string        `env:"RULES_FILE"`
+	CacheDir    string        `env:"CACHE_DIR"`
+
+	Output     string   `env:"OUTPUT"      env-default:"csv"`
+	OutputPath string   `env:"OUTPUT_PATH"`
+	Columns    []string `env:"COLUMNS"`
+	Failures   string   `env:"FAILURES"`
+
+	Resume   string        `env:"RESUME"`
+	StateTTL time.Duration `env:"STATE_TTL" env-default:"0s"`
+
+	RetryStatuses  []int         `env:"RETRY_STATUSES"   env-default:"429,500,502,503,504"`
+	RetryErrors    []string      `env:"RETRY_ERRORS"     env-default:"timeout,connection"`
+	RetryBaseDelay time.Duration `env:"RETRY_BASE_DELAY" env-default:"500ms"`
+	RetryMaxDelay  time.Duration `env:"RETRY_MAX_DELAY"  env-default:"30s"`
+
+	Crawl    bool     `env:"CRAWL"     env-default:"false"`
+	MaxDepth int      `env:"MAX_DEPTH" env-default:"2"`
+	MaxPages int      `env:"MAX_PAGES" env-default:"100"`
+	Include  []string `env:"INCLUDE"`
+	Exclude  []string `env:"EXCLUDE"`
+
+	MaxIdleConns        int           `env:"MAX_IDLE_CONNS"          env-default:"100"`
+	MaxIdleConnsPerHost int           `env:"MAX_IDLE_CONNS_PER_HOST" env-default:"10"`
+	IdleConnTimeout     time.Duration `env:"IDLE_CONN_TIMEOUT"       env-default:"90s"`
+	KeepAlive           time.Duration `env:"KEEP_ALIVE"              env-default:"30s"`
+	HTTP2               bool          `env:"HTTP2"                   env-default:"true"`
+	Proxy               string        `env:"PROXY"`
+	CAFile              string        `env:"CA_FILE"`
+	InsecureSkipVerify  bool          `env:"INSECURE_SKIP_VERIFY"    env-default:"false"`
+	MaxRedirects        int           `env:"MAX_REDIRECTS"           env-default:"10"`
+
+	UserAgent       string        `env:"USER_AGENT"       env-default:"work4-scraper/1.0"`
+	Robots          bool          `env:"ROBOTS"           env-default:"true"`
+	HostConcurrency int           `env:"HOST_CONCURRENCY" env-default:"2"`
+	HostDelay       time.Duration `env:"HOST_DELAY"       env-default:"500ms"`
 }
 
 func (c *Config) String() string {
-	return fmt.Sprintf("{ThreadCount:%d Timeout:%s RetryCount:%d}", c.ThreadCount, c.Timeout, c.RetryCount)
+	return fmt.Sprintf(
+		"{ThreadCount:%d Timeout:%s RetryCount:%d Deadline:%s RulesFile:%q CacheDir:%q "+
+			"Output:%q OutputPath:%q Columns:%q Failures:%q Resume:%q StateTTL:%s "+
+			"RetryStatuses:%v RetryErrors:%q RetryBaseDelay:%s RetryMaxDelay:%s "+
+			"Crawl:%t MaxDepth:%d MaxPages:%d Include:%q Exclude:%q "+
+			"MaxIdleConns:%d MaxIdleConnsPerHost:%d IdleConnTimeout:%s KeepAlive:%s HTTP2:%t "+
+			"Proxy:%q CAFile:%q InsecureSkipVerify:%t MaxRedirects:%d "+
+			"UserAgent:%q Robots:%t HostConcurrency:%d HostDelay:%s}",
+		c.ThreadCount, c.Timeout, c.RetryCount, c.Deadline, c.RulesFile, c.CacheDir,
+		c.Output, c.OutputPath, c.Columns, c.Failures, c.Resume, c.StateTTL,
+		c.RetryStatuses, c.RetryErrors, c.RetryBaseDelay, c.RetryMaxDelay,
+		c.Crawl, c.MaxDepth, c.MaxPages, c.Include, c.Exclude,
+		c.MaxIdleConns, c.MaxIdleConnsPerHost, c.IdleConnTimeout, c.KeepAlive, c.HTTP2,
+		c.Proxy, c.CAFile, c.InsecureSkipVerify, c.MaxRedirects,
+		c.UserAgent, c.Robots, c.HostConcurrency, c.HostDelay,
+	)
 }
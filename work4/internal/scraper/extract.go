@@ -0,0 +1,219 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+var ErrInvalidRule = errors.New("invalid extraction rule")
+
+// Rules is the extraction rules file: built-in extractors and custom fields.
+type Rules struct {
+	Builtins []string `yaml:"builtins" json:"builtins"`
+	Fields   []*Rule  `yaml:"fields"   json:"fields"`
+}
+
+// Rule extracts a named field: the text or an attribute of the elements matching
+// the CSS selector, the first one unless Multiple is set. A Regex keeps the
+// first submatch (or the whole match) and drops values that don't match.
+type Rule struct {
+	Name     string `yaml:"name"     json:"name"`
+	Selector string `yaml:"selector" json:"selector"`
+	Attr     string `yaml:"attr"     json:"attr"`
+	Multiple bool   `yaml:"multiple" json:"multiple"`
+	Regex    string `yaml:"regex"    json:"regex"`
+
+	matcher cascadia.Selector
+	re      *regexp.Regexp
+	format  func(value string) (string, bool)
+}
+
+// defaultRules are used when no rules file is configured.
+var defaultRules = []*Rule{
+	{Name: "title", Selector: "head title"},
+	{Name: "description", Selector: "head meta[name='description']", Attr: "content"},
+}
+
+// builtins are the predefined extractors selectable by name.
+var builtins = map[string][]*Rule{
+	"opengraph": metaRules("property", "og:title", "og:description", "og:type", "og:url", "og:image", "og:site_name"),
+	"twitter":   metaRules("name", "twitter:card", "twitter:title", "twitter:description", "twitter:image", "twitter:site"),
+	"canonical": {{Name: "canonical", Selector: "link[rel='canonical']", Attr: "href"}},
+	"lang":      {{Name: "lang", Selector: "html", Attr: "lang"}},
+	"headings": {
+		{Name: "h1", Selector: "h1", Multiple: true},
+		{Name: "h2", Selector: "h2", Multiple: true},
+		{Name: "h3", Selector: "h3", Multiple: true},
+	},
+	"jsonld": {{Name: "jsonld", Selector: "script[type='application/ld+json']", Multiple: true, format: compactJSON}},
+}
+
+func metaRules(attr string, names ...string) []*Rule {
+	rules := make([]*Rule, 0, len(names))
+
+	for _, name := range names {
+		rules = append(rules, &Rule{Name: name, Selector: fmt.Sprintf("meta[%s='%s']", attr, name), Attr: "content"})
+	}
+
+	return rules
+}
+
+// Extractor applies the extraction rules to parsed documents.
+type Extractor struct {
+	rules []*Rule
+}
+
+func NewExtractor(conf *Config) (*Extractor, error) {
+	if conf.RulesFile == "" {
+		return newExtractor(defaultRules)
+	}
+
+	var file Rules
+	if err := cleanenv.ReadConfig(conf.RulesFile, &file); err != nil {
+		return nil, fmt.Errorf("rules file: %w", err)
+	}
+
+	rules := make([]*Rule, 0, len(file.Fields))
+
+	for _, name := range file.Builtins {
+		builtin, ok := builtins[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown builtin %q", ErrInvalidRule, name)
+		}
+
+		rules = append(rules, builtin...)
+	}
+
+	return newExtractor(append(rules, file.Fields...))
+}
+
+func newExtractor(rules []*Rule) (*Extractor, error) {
+	names := make([]string, 0, len(rules))
+	compiled := make([]*Rule, 0, len(rules))
+
+	for _, rule := range rules {
+		if rule.Name == "" || slices.Contains(names, rule.Name) {
+			return nil, fmt.Errorf("%w: empty or duplicate name %q", ErrInvalidRule, rule.Name)
+		}
+
+		if slices.Contains(Columns, rule.Name) {
+			return nil, fmt.Errorf("%w: name %q is a built-in column", ErrInvalidRule, rule.Name)
+		}
+
+		c := *rule
+
+		matcher, err := cascadia.Compile(rule.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: selector: %w", ErrInvalidRule, rule.Name, err)
+		}
+
+		c.matcher = matcher
+
+		if rule.Regex != "" {
+			if c.re, err = regexp.Compile(rule.Regex); err != nil {
+				return nil, fmt.Errorf("%w: %s: regex: %w", ErrInvalidRule, rule.Name, err)
+			}
+		}
+
+		names = append(names, rule.Name)
+		compiled = append(compiled, &c)
+	}
+
+	return &Extractor{rules: compiled}, nil
+}
+
+// Names returns the field names in rule order.
+func (e *Extractor) Names() []string {
+	names := make([]string, 0, len(e.rules))
+
+	for _, rule := range e.rules {
+		names = append(names, rule.Name)
+	}
+
+	return names
+}
+
+// Extract returns the values of the fields found in the document.
+func (e *Extractor) Extract(doc *goquery.Document) map[string][]string {
+	values := make(map[string][]string, len(e.rules))
+
+	for _, rule := range e.rules {
+		if found := rule.extract(doc); len(found) > 0 {
+			values[rule.Name] = found
+		}
+	}
+
+	return values
+}
+
+func (r *Rule) extract(doc *goquery.Document) []string {
+	values := make([]string, 0)
+
+	doc.FindMatcher(r.matcher).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		value, ok := r.value(s)
+		if ok {
+			values = append(values, value)
+		}
+
+		return r.Multiple || !ok
+	})
+
+	return values
+}
+
+func (r *Rule) value(s *goquery.Selection) (string, bool) {
+	var value string
+
+	switch {
+	case r.Attr != "":
+		attr, ok := s.Attr(r.Attr)
+		if !ok {
+			return "", false
+		}
+
+		value = strings.TrimSpace(attr)
+	case r.format != nil:
+		value = s.Text()
+	default:
+		value = strings.Join(strings.Fields(s.Text()), " ")
+	}
+
+	if r.format != nil {
+		return r.format(value)
+	}
+
+	if r.re == nil {
+		return value, value != ""
+	}
+
+	match := r.re.FindStringSubmatch(value)
+
+	switch {
+	case match == nil:
+		return "", false
+	case len(match) > 1:
+		return match[1], true
+	default:
+		return match[0], true
+	}
+}
+
+// compactJSON keeps valid JSON-LD blocks on a single line.
+func compactJSON(value string) (string, bool) {
+	var buf bytes.Buffer
+
+	if err := json.Compact(&buf, []byte(value)); err != nil {
+		return "", false
+	}
+
+	return buf.String(), true
+}
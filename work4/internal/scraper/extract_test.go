@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testPage = `<html lang="en"><head>
+<title> Example
+  Page </title>
+<meta property="og:title" content="OG title">
+</head><body>
+<h1>Products</h1>
+<span class="price">Price: 12.50 USD</span>
+<span class="price">Price: on request</span>
+<span class="price">Price: 7 USD</span>
+</body></html>`
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	e, err := newExtractor([]*Rule{
+		{Name: "title", Selector: "head title"},
+		{Name: "lang", Selector: "html", Attr: "lang"},
+		{Name: "price", Selector: ".price", Regex: `([0-9]+(?:\.[0-9]+)?)`, Multiple: true},
+		{Name: "missing", Selector: ".missing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(testPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := e.Extract(doc)
+
+	if !slices.Equal(values["title"], []string{"Example Page"}) || !slices.Equal(values["lang"], []string{"en"}) {
+		t.Errorf("title %q, lang %q", values["title"], values["lang"])
+	}
+
+	if !slices.Equal(values["price"], []string{"12.50", "7"}) {
+		t.Errorf("price = %q", values["price"])
+	}
+
+	if _, ok := values["missing"]; ok {
+		t.Error("missing field present")
+	}
+}
+
+func TestExtractorRuleNames(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		rules []*Rule
+	}{
+		{"empty", []*Rule{{Selector: "h1"}}},
+		{"duplicate", []*Rule{{Name: "h1", Selector: "h1"}, {Name: "h1", Selector: "h1"}}},
+		{"url column", []*Rule{{Name: "url", Selector: "link[rel='canonical']", Attr: "href"}}},
+		{"status_code column", []*Rule{{Name: "status_code", Selector: "h1"}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := newExtractor(tt.rules); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+}
+
+func TestNewExtractorRulesFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rules.yml")
+	data := "builtins: [lang]\nfields:\n  - name: date\n    selector: time\n"
+
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewExtractor(&Config{RulesFile: path}); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("rule named date: err = %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package scraper
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Task is a URL to fetch and its distance in links from the seed URL.
+type Task struct {
+	URL   string
+	Depth int
+}
+
+// State persists the frontier so an interrupted crawl can be resumed. URLs
+// are identified by their normalized form.
+type State interface {
+	// Load returns the URLs already processed and the tasks left to process,
+	// including the ones that were in flight.
+	Load() (done []string, pending []*Task, err error)
+	Queue(key string, task *Task) error
+	Start(key string) error
+	Finish(key string, outcome string) error
+}
+
+// Frontier is the deduplicated queue of URLs to fetch. Outside of crawl mode
+// it only holds the seed URLs.
+type Frontier struct {
+	crawl    bool
+	maxDepth int
+	maxPages int
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+	state    State
+
+	seen    map[string]struct{}
+	queued  int // pages queued in this run, limited by maxPages
+	queue   []*Task
+	stopped bool
+}
+
+// NewFrontier creates the frontier, restoring it from the state if one is given.
+func NewFrontier(conf *Config, state State) (*Frontier, error) {
+	include, err := compile(conf.Include)
+	if err != nil {
+		return nil, fmt.Errorf("include pattern: %w", err)
+	}
+
+	exclude, err := compile(conf.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("exclude pattern: %w", err)
+	}
+
+	f := &Frontier{
+		crawl:    conf.Crawl,
+		maxDepth: conf.MaxDepth,
+		maxPages: conf.MaxPages,
+		include:  include,
+		exclude:  exclude,
+		state:    state,
+		seen:     make(map[string]struct{}),
+	}
+
+	if state == nil {
+		return f, nil
+	}
+
+	done, pending, err := state.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load state: %w", err)
+	}
+
+	for _, key := range done {
+		f.seen[key] = struct{}{}
+	}
+
+	for _, task := range pending {
+		f.seen[normalize(task.URL)] = struct{}{}
+		f.queue = append(f.queue, task)
+	}
+
+	f.queued = len(pending)
+
+	slog.Info("crawl resumed", slog.Int("done", len(done)), slog.Int("pending", len(pending)))
+
+	return f, nil
+}
+
+// Seed queues a URL given by the user; include/exclude patterns apply to discovered links only.
+func (f *Frontier) Seed(rawURL string) {
+	f.push(&Task{URL: rawURL}, false)
+}
+
+// Done queues the links found on a crawled page and records the outcome.
+// Cancelled URLs stay pending in the state to be fetched on resume.
+func (f *Frontier) Done(res *Result) {
+	if res.Err == nil {
+		f.discover(res)
+	}
+
+	if f.state != nil && res.Outcome != OutcomeCancelled {
+		f.record(f.state.Finish(normalize(res.URL), res.Outcome))
+	}
+}
+
+func (f *Frontier) discover(res *Result) {
+	if !f.crawl || res.Depth >= f.maxDepth {
+		return
+	}
+
+	for _, link := range res.Links {
+		f.push(&Task{URL: link, Depth: res.Depth + 1}, true)
+	}
+}
+
+// Stop drops the queued tasks. URLs found afterwards are only recorded in the
+// state, so a resumed crawl fetches them.
+func (f *Frontier) Stop() {
+	f.stopped = true
+	f.queue = nil
+}
+
+func (f *Frontier) Len() int {
+	return len(f.queue)
+}
+
+func (f *Frontier) Next() *Task {
+	return f.queue[0]
+}
+
+func (f *Frontier) Pop() {
+	if f.state != nil {
+		f.record(f.state.Start(normalize(f.queue[0].URL)))
+	}
+
+	f.queue[0] = nil
+	f.queue = f.queue[1:]
+}
+
+func (f *Frontier) push(task *Task, filter bool) {
+	key := normalize(task.URL)
+
+	if _, ok := f.seen[key]; ok {
+		return
+	}
+
+	// pages done in a previous run do not count: max pages limits a single run
+	if f.crawl && f.maxPages > 0 && f.queued >= f.maxPages {
+		return
+	}
+
+	if filter && !f.allowed(key) {
+		return
+	}
+
+	f.seen[key] = struct{}{}
+	f.queued++
+
+	if f.state != nil {
+		f.record(f.state.Queue(key, task))
+	}
+
+	if !f.stopped {
+		f.queue = append(f.queue, task)
+	}
+}
+
+// record logs state errors; the crawl goes on without a consistent state.
+func (f *Frontier) record(err error) {
+	if err != nil {
+		slog.Error("state error", slog.String("err", err.Error()))
+	}
+}
+
+func (f *Frontier) allowed(rawURL string) bool {
+	for _, re := range f.exclude {
+		if re.MatchString(rawURL) {
+			return false
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+
+	for _, re := range f.include {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compile(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		res = append(res, re)
+	}
+
+	return res, nil
+}
+
+// normalize makes equal URLs compare equal: lower-case scheme and host, no fragment, "/" for an empty path.
+func normalize(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String()
+}
+
+// sameSite reports whether both URLs are on the same host, ignoring a "www." prefix.
+func sameSite(a, b *url.URL) bool {
+	return strings.TrimPrefix(strings.ToLower(a.Hostname()), "www.") ==
+		strings.TrimPrefix(strings.ToLower(b.Hostname()), "www.")
+}
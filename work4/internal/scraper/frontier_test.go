@@ -0,0 +1,217 @@
+package scraper
+
+import (
+	"slices"
+	"testing"
+)
+
+// memState is an in-memory State keeping the status of every URL by key.
+type memState struct {
+	keys   []string
+	tasks  map[string]*Task
+	status map[string]string
+}
+
+func newMemState() *memState {
+	return &memState{tasks: make(map[string]*Task), status: make(map[string]string)}
+}
+
+func (s *memState) Load() ([]string, []*Task, error) {
+	var (
+		done    []string
+		pending []*Task
+	)
+
+	for _, key := range s.keys {
+		if s.status[key] == "done" {
+			done = append(done, key)
+		} else {
+			pending = append(pending, s.tasks[key])
+		}
+	}
+
+	return done, pending, nil
+}
+
+func (s *memState) Queue(key string, task *Task) error {
+	if _, ok := s.tasks[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+
+	s.tasks[key] = task
+	s.status[key] = "queued"
+
+	return nil
+}
+
+func (s *memState) Start(key string) error {
+	s.status[key] = "in_flight"
+
+	return nil
+}
+
+func (s *memState) Finish(key string, _ string) error {
+	s.status[key] = "done"
+
+	return nil
+}
+
+func drain(f *Frontier) []string {
+	var urls []string
+
+	for f.Len() > 0 {
+		urls = append(urls, f.Next().URL)
+		f.Pop()
+	}
+
+	return urls
+}
+
+func TestFrontierCrawl(t *testing.T) {
+	t.Parallel()
+
+	conf := &Config{Crawl: true, MaxDepth: 1, MaxPages: 10, Exclude: []string{`\.pdf$`}}
+
+	f, err := NewFrontier(conf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Seed("https://Example.com")
+	f.Seed("https://example.com/")
+
+	if got := drain(f); !slices.Equal(got, []string{"https://Example.com"}) {
+		t.Fatalf("seeds = %q", got)
+	}
+
+	f.Done(&Result{URL: "https://Example.com", Depth: 0, Outcome: OutcomeOK, Links: []string{
+		"https://example.com/a#top",
+		"https://example.com/a",
+		"https://example.com/doc.pdf",
+		"https://example.com/b",
+	}})
+
+	if got := drain(f); !slices.Equal(got, []string{"https://example.com/a#top", "https://example.com/b"}) {
+		t.Fatalf("links = %q", got)
+	}
+
+	f.Done(&Result{URL: "https://example.com/a", Depth: 1, Outcome: OutcomeOK, Links: []string{"https://example.com/c"}})
+
+	if f.Len() != 0 {
+		t.Fatalf("links beyond max depth queued: %d", f.Len())
+	}
+}
+
+func TestFrontierResume(t *testing.T) {
+	t.Parallel()
+
+	conf := &Config{Crawl: true, MaxDepth: 2, MaxPages: 10}
+	state := newMemState()
+
+	f, err := NewFrontier(conf, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Seed("https://example.com/")
+	f.Pop()
+	f.Done(&Result{URL: "https://example.com/", Outcome: OutcomeOK, Links: []string{
+		"https://example.com/a", "https://example.com/b",
+	}})
+
+	// /a is in flight when the crawl is interrupted, /b is dropped from the queue
+	f.Pop()
+	f.Stop()
+
+	if f.Len() != 0 {
+		t.Fatalf("queue not dropped on stop: %d", f.Len())
+	}
+
+	// the in-flight page finishes during the drain; its links are kept for resume
+	f.Done(&Result{URL: "https://example.com/a", Depth: 1, Outcome: OutcomeOK, Links: []string{"https://example.com/c"}})
+
+	if f.Len() != 0 {
+		t.Fatalf("link queued after stop: %d", f.Len())
+	}
+
+	resumed, err := NewFrontier(conf, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"https://example.com/b", "https://example.com/c"}
+	if got := drain(resumed); !slices.Equal(got, want) {
+		t.Fatalf("resumed = %q, want %q", got, want)
+	}
+
+	// done URLs are not fetched again
+	resumed.Seed("https://example.com/a")
+
+	if resumed.Len() != 0 {
+		t.Fatal("done URL queued again")
+	}
+}
+
+func TestFrontierResumeCancelled(t *testing.T) {
+	t.Parallel()
+
+	state := newMemState()
+
+	f, err := NewFrontier(&Config{}, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Seed("https://example.com/")
+	f.Pop()
+	f.Done(&Result{URL: "https://example.com/", Outcome: OutcomeCancelled})
+
+	resumed, err := NewFrontier(&Config{}, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := drain(resumed); !slices.Equal(got, []string{"https://example.com/"}) {
+		t.Fatalf("resumed = %q", got)
+	}
+}
+
+func TestFrontierResumeMaxPages(t *testing.T) {
+	t.Parallel()
+
+	conf := &Config{Crawl: true, MaxDepth: 2, MaxPages: 2}
+	state := newMemState()
+
+	f, err := NewFrontier(conf, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Seed("https://example.com/")
+	f.Pop()
+	f.Done(&Result{URL: "https://example.com/", Outcome: OutcomeOK, Links: []string{
+		"https://example.com/a", "https://example.com/b",
+	}})
+
+	if f.Len() != 1 || f.Next().URL != "https://example.com/a" {
+		t.Fatalf("first run queued %d pages", f.Len())
+	}
+
+	f.Pop()
+	f.Done(&Result{URL: "https://example.com/a", Depth: 1, Outcome: OutcomeOK})
+
+	// a resumed run gets its own max pages, not what is left of the previous one
+	resumed, err := NewFrontier(conf, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed.Done(&Result{URL: "https://example.com/a", Depth: 1, Outcome: OutcomeOK, Links: []string{
+		"https://example.com/b", "https://example.com/c", "https://example.com/d",
+	}})
+
+	want := []string{"https://example.com/b", "https://example.com/c"}
+	if got := drain(resumed); !slices.Equal(got, want) {
+		t.Fatalf("resumed = %q, want %q", got, want)
+	}
+}
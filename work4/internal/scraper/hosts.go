@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Hosts limits concurrent requests and the request rate per host, independently
+// of the number of workers.
+type Hosts struct {
+	concurrency int
+	delay       time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+type host struct {
+	slots chan struct{}
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func NewHosts(concurrency int, delay time.Duration) *Hosts {
+	return &Hosts{concurrency: max(concurrency, 1), delay: delay, hosts: make(map[string]*host)}
+}
+
+// Acquire waits for a free slot and the delay since the previous request to the
+// host, the larger of the configured delay and crawlDelay. The returned func
+// releases the slot.
+func (hs *Hosts) Acquire(ctx context.Context, name string, crawlDelay time.Duration) (func(), error) {
+	hs.mu.Lock()
+	h, ok := hs.hosts[name]
+	if !ok {
+		h = &host{slots: make(chan struct{}, hs.concurrency)}
+		hs.hosts[name] = h
+	}
+	hs.mu.Unlock()
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("host slot: %w", ctx.Err())
+	}
+
+	h.mu.Lock()
+	now := time.Now()
+	start := now
+
+	if h.next.After(now) {
+		start = h.next
+	}
+
+	h.next = start.Add(max(hs.delay, crawlDelay))
+	h.mu.Unlock()
+
+	release := func() { <-h.slots }
+
+	if err := sleep(ctx, start.Sub(now)); err != nil {
+		release()
+
+		return nil, fmt.Errorf("host delay: %w", err)
+	}
+
+	return release, nil
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	}
+}
@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Outcomes of processing a URL; failed requests are categorized by ErrorClass.
+const (
+	OutcomeOK         = "ok"
+	OutcomeInvalidURL = "invalid_url"
+	OutcomeRobots     = "robots"
+	OutcomeRedirects  = "redirects"
+	OutcomeHTTP4xx    = "http_4xx"
+	OutcomeHTTP5xx    = "http_5xx"
+	OutcomeParse      = "parse"
+	OutcomeCancelled  = "cancelled"
+)
+
+var (
+	ErrInvalidURL = errors.New("invalid url")
+	ErrParse      = errors.New("parse error")
+)
+
+// outcome categorizes the result of processing a URL by its error.
+func outcome(res *Result) string {
+	switch err := res.Err; {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, ErrInvalidURL):
+		return OutcomeInvalidURL
+	case errors.Is(err, ErrDisallowed):
+		return OutcomeRobots
+	case errors.Is(err, ErrTooManyRedirects):
+		return OutcomeRedirects
+	case errors.Is(err, ErrBadStatus) && res.StatusCode >= 500: //nolint:mnd
+		return OutcomeHTTP5xx
+	case errors.Is(err, ErrBadStatus):
+		return OutcomeHTTP4xx
+	case errors.Is(err, ErrParse):
+		return OutcomeParse
+	case errors.Is(err, context.Canceled):
+		return OutcomeCancelled
+	}
+
+	return ErrorClass(res.Err)
+}
+
+// Summary counts the results per outcome.
+type Summary struct {
+	start    time.Time
+	total    int
+	outcomes map[string]int
+}
+
+func NewSummary() *Summary {
+	return &Summary{start: time.Now(), outcomes: make(map[string]int)}
+}
+
+func (s *Summary) Add(res *Result) {
+	s.total++
+	s.outcomes[res.Outcome]++
+}
+
+// Log writes the summary as a single log record.
+func (s *Summary) Log(logger *slog.Logger) {
+	attrs := []any{slog.Int("total", s.total), slog.Duration("duration", time.Since(s.start))}
+
+	outcomes := make([]any, 0, len(s.outcomes))
+	for _, name := range slices.Sorted(maps.Keys(s.outcomes)) {
+		outcomes = append(outcomes, slog.Int(name, s.outcomes[name]))
+	}
+
+	logger.Info("crawl finished", append(attrs, slog.Group("outcomes", outcomes...))...)
+}
@@ -1,91 +1,254 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"golang.org/x/net/html/charset"
 )
 
+var (
+	ErrBadStatus  = errors.New("bad status")
+	ErrDisallowed = errors.New("disallowed by robots.txt")
+)
+
+// Processor fetches pages; Client, Extractor, Retry, Robots, Hosts and Cache
+// are shared by all workers, Robots and Cache are nil when disabled.
 type Processor struct {
-	Timeout    time.Duration
-	RetryCount int
+	Client    *http.Client
+	Crawl     bool
+	UserAgent string
+	Extractor *Extractor
+	Retry     *RetryPolicy
+	Robots    *Robots
+	Hosts     *Hosts
+	Cache     *Cache
 }
 
 type Result struct {
-	Date        time.Time
-	URL         string
-	Title       string
-	Description string
-	StatusCode  int
+	Date       time.Time
+	URL        string
+	StatusCode int
+	// FinalURL is the URL of the page after redirects.
+	FinalURL string
+	// Values are the extracted fields by name.
+	Values map[string][]string
+	// Unchanged is set when the page was served from the cache, either still
+	// fresh or confirmed by the server with 304 Not Modified.
+	Unchanged bool
+
+	// Depth is the distance in links from the seed URL; Links are the same-site
+	// links found on the page in crawl mode.
+	Depth int
+	Links []string
+	// Err is set when the page could not be fetched or parsed, Outcome
+	// categorizes it. Attempts counts the requests made, Duration covers the
+	// whole processing including politeness delays.
+	Err      error
+	Outcome  string
+	Attempts int
+	Duration time.Duration
 }
 
 type pageInfo struct {
-	Title       string
-	Description string
+	Values map[string][]string
+	Links  []string
 }
 
-func NewProcessor(conf *Config) *Processor {
-	return &Processor{Timeout: conf.Timeout, RetryCount: conf.RetryCount}
+func NewProcessor(
+	conf *Config, client *http.Client, extractor *Extractor, retry *RetryPolicy, robots *Robots, hosts *Hosts, cache *Cache,
+) *Processor {
+	return &Processor{
+		Client:    client,
+		Crawl:     conf.Crawl,
+		UserAgent: conf.UserAgent,
+		Extractor: extractor,
+		Retry:     retry,
+		Robots:    robots,
+		Hosts:     hosts,
+		Cache:     cache,
+	}
 }
 
-func (p *Processor) Do(in <-chan string, out chan<- *Result) {
-	for url := range in {
-		res, err := p.get(url)
-		if err != nil {
-			slog.Error("processing error", slog.String("url", url), slog.String("err", err.Error()))
-			continue
+// Do processes tasks until the input is closed, sending one result per task.
+func (p *Processor) Do(ctx context.Context, in <-chan *Task, out chan<- *Result) {
+	for task := range in {
+		result := &Result{Date: time.Now(), URL: task.URL, Depth: task.Depth}
+
+		result.Err = p.process(ctx, result)
+		result.Outcome = outcome(result)
+
+		// the crawl deadline cuts requests short, they are not failures of the url
+		if result.Err != nil && ctx.Err() != nil {
+			result.Outcome = OutcomeCancelled
 		}
+		result.Duration = time.Since(result.Date)
 
-		if res.StatusCode >= 400 { //nolint:mnd
-			slog.Error("processing error", slog.String("url", url), slog.String("err", res.Status), slog.Int("status_code", res.StatusCode))
-			continue
+		if result.Err != nil {
+			slog.Error("processing error", slog.String("url", task.URL), slog.String("err", result.Err.Error()),
+				slog.String("outcome", result.Outcome), slog.Int("status_code", result.StatusCode), slog.Int("attempts", result.Attempts))
 		}
 
-		info, err := p.parse(res)
-		if err != nil {
-			slog.Error("processing error", slog.String("url", url), slog.String("err", err.Error()))
-			continue
+		out <- result
+	}
+}
+
+func (p *Processor) process(ctx context.Context, result *Result) error {
+	u, err := url.Parse(result.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	var cached *CacheEntry
+
+	if p.Cache != nil {
+		cached = p.Cache.Load(result.URL)
+	}
+
+	// a fresh page makes no request, so robots.txt is not needed to serve it
+	if cached != nil && cached.Fresh(time.Now()) {
+		result.Unchanged = true
+
+		return p.finish(result, cached.Response(nil))
+	}
+
+	var crawlDelay time.Duration
+
+	if p.Robots != nil {
+		rules := p.Robots.Rules(ctx, u)
+		if !rules.Allowed(u) {
+			return ErrDisallowed
+		}
+
+		crawlDelay = rules.CrawlDelay
+	}
+
+	release, err := p.Hosts.Acquire(ctx, u.Host, crawlDelay)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var header http.Header
+	if cached != nil {
+		header = cached.Conditional()
+	}
+
+	res, err := p.get(ctx, result, header)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cached != nil && res.StatusCode == http.StatusNotModified:
+		discard(res)
+
+		if err := p.Cache.Revalidated(cached, res); err != nil {
+			slog.Warn("cache error", slog.String("url", result.URL), slog.String("err", err.Error()))
+		}
+
+		result.Unchanged = true
+		res = cached.Response(res.Request.URL)
+	case p.Cache != nil:
+		stored, err := p.Cache.Store(result.URL, res)
+		if stored == nil {
+			return err
 		}
 
-		out <- &Result{
-			Date:        time.Now(),
-			URL:         url,
-			StatusCode:  res.StatusCode,
-			Title:       info.Title,
-			Description: info.Description,
+		if err != nil {
+			slog.Warn("cache error", slog.String("url", result.URL), slog.String("err", err.Error()))
 		}
+
+		res = stored
 	}
+
+	return p.finish(result, res)
 }
 
-func (p *Processor) get(url string) (*http.Response, error) {
-	client := http.Client{Timeout: p.Timeout}
+// finish parses a successful response into the result.
+func (p *Processor) finish(result *Result, res *http.Response) error {
+	result.StatusCode = res.StatusCode
+	result.FinalURL = res.Request.URL.String()
 
-	req, err := http.NewRequest(http.MethodGet, url, nil) //nolint:noctx
+	// redirects not followed by the redirect policy have nothing to parse
+	if res.StatusCode >= 300 && res.StatusCode < 400 { //nolint:mnd
+		discard(res)
+
+		return nil
+	}
+
+	if res.StatusCode >= 400 { //nolint:mnd
+		res.Body.Close()
+
+		return fmt.Errorf("%w: %s", ErrBadStatus, res.Status)
+	}
+
+	info, err := p.parse(res)
 	if err != nil {
-		return nil, fmt.Errorf("request error: %w", err)
+		return err
 	}
 
-	var res *http.Response
+	result.Values = info.Values
+	result.Links = info.Links
+
+	return nil
+}
+
+// get fetches the url, retrying as the retry policy allows. The bodies of
+// retried responses are drained and closed.
+func (p *Processor) get(ctx context.Context, result *Result, header http.Header) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
 
-	for range p.RetryCount {
-		if res, err = client.Do(req); err != nil {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, result.URL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("request error: %w", err)
+		}
+
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		req.Header.Set("User-Agent", p.UserAgent)
+
+		res, err := p.Client.Do(req)
+		if err != nil && ctx.Err() != nil {
 			return nil, fmt.Errorf("response error: %w", err)
 		}
 
-		if res.StatusCode == http.StatusServiceUnavailable {
-			slog.Warn("page is temporarily unavailable, retrying")
-			continue
+		delay, retry := p.Retry.Next(attempt, res, err)
+		if !retry {
+			if err != nil {
+				return nil, fmt.Errorf("response error: %w", err)
+			}
+
+			return res, nil
+		}
+
+		attrs := []any{slog.String("url", result.URL), slog.Int("attempt", attempt), slog.Duration("delay", delay)}
+
+		if err != nil {
+			attrs = append(attrs, slog.String("err", err.Error()), slog.String("error_class", ErrorClass(err)))
+		} else {
+			attrs = append(attrs, slog.Int("status_code", res.StatusCode))
+
+			discard(res)
 		}
 
-		return res, nil
-	}
+		slog.Warn("request failed, retrying", attrs...)
 
-	return res, nil
+		if err := sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("response error: %w", err)
+		}
+	}
 }
 
 func (p *Processor) parse(res *http.Response) (*pageInfo, error) {
@@ -94,34 +257,97 @@ func (p *Processor) parse(res *http.Response) (*pageInfo, error) {
 
 	reader, err := charset.NewReader(res.Body, ct)
 	if err != nil {
-		return nil, fmt.Errorf("charset detection error: %w", err)
+		return nil, fmt.Errorf("%w: charset detection: %w", ErrParse, err)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(reader)
 	if err != nil {
-		return nil, fmt.Errorf("document read error: %w", err)
+		return nil, fmt.Errorf("%w: document read: %w", ErrParse, err)
 	}
 
-	info := pageInfo{}
+	info := pageInfo{Values: p.Extractor.Extract(doc)}
 
-	doc.Find("head").Each(func(_ int, s *goquery.Selection) {
-		info.Title = s.Find("title").Text()
+	if p.Crawl {
+		info.Links = links(doc, res.Request.URL)
+	}
 
-		value, exist := s.Find("meta[name='description']").Attr("content")
-		if exist {
-			info.Description = value
+	return &info, nil
+}
+
+// links returns the unique http(s) links of the document on the same site as
+// the page. Hrefs are resolved against the <base> URL if there is one, but the
+// site is always the one of the page.
+func links(doc *goquery.Document, page *url.URL) []string {
+	base := page
+
+	if href, ok := doc.Find("base[href]").Attr("href"); ok {
+		if u, err := page.Parse(href); err == nil {
+			base = u
+		}
+	}
+
+	seen := make(map[string]struct{})
+	res := make([]string, 0)
+
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+
+		u, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !sameSite(u, page) {
+			return
+		}
+
+		u.Fragment = ""
+		link := u.String()
+
+		if _, ok := seen[link]; !ok {
+			seen[link] = struct{}{}
+			res = append(res, link)
 		}
 	})
 
-	return &info, nil
+	return res
+}
+
+// Columns are the result columns preceding the extracted fields.
+var Columns = []string{"date", "url", "final_url", "status_code", "unchanged", "outcome", "error", "attempts", "duration_ms"}
+
+// Field returns a column value; multiple values of a field are joined with " | ".
+func (rs *Result) Field(column string) string {
+	switch column {
+	case "date":
+		return rs.Date.Format(time.RFC3339)
+	case "url":
+		return rs.URL
+	case "final_url":
+		return rs.FinalURL
+	case "status_code":
+		return strconv.Itoa(rs.StatusCode)
+	case "unchanged":
+		return strconv.FormatBool(rs.Unchanged)
+	case "outcome":
+		return rs.Outcome
+	case "error":
+		if rs.Err == nil {
+			return ""
+		}
+
+		return rs.Err.Error()
+	case "attempts":
+		return strconv.Itoa(rs.Attempts)
+	case "duration_ms":
+		return strconv.FormatInt(rs.Duration.Milliseconds(), 10)
+	}
+
+	return strings.Join(rs.Values[column], " | ")
 }
 
-func (rs *Result) Fields() []string {
-	return []string{
-		rs.Date.Format(time.RFC3339),
-		rs.URL,
-		strconv.Itoa(rs.StatusCode),
-		rs.Title,
-		rs.Description,
+func (rs *Result) Fields(columns []string) []string {
+	fields := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		fields = append(fields, rs.Field(column))
 	}
+
+	return fields
 }
@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func pageLinks(t *testing.T, pageURL, html string) []string {
+	t.Helper()
+
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return links(doc, page)
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+
+	got := pageLinks(t, "https://example.com/docs/", `<html><body>
+<a href="intro">intro</a>
+<a href="/about#team">about</a>
+<a href="/about">about again</a>
+<a href="https://www.example.com/blog">blog</a>
+<a href="https://other.example/">other</a>
+<a href="mailto:team@example.com">mail</a>
+</body></html>`)
+
+	want := []string{"https://example.com/docs/intro", "https://example.com/about", "https://www.example.com/blog"}
+	if !slices.Equal(got, want) {
+		t.Errorf("links = %q, want %q", got, want)
+	}
+}
+
+func TestLinksForeignBase(t *testing.T) {
+	t.Parallel()
+
+	got := pageLinks(t, "https://example.com/page", `<html><head><base href="https://other.example/"></head><body>
+<a href="relative">relative</a>
+<a href="https://other.example/absolute">absolute</a>
+<a href="https://example.com/same">same</a>
+</body></html>`)
+
+	if want := []string{"https://example.com/same"}; !slices.Equal(got, want) {
+		t.Fatalf("links = %q, want %q", got, want)
+	}
+
+	f, err := NewFrontier(&Config{Crawl: true, MaxDepth: 2, MaxPages: 10}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Seed("https://example.com/page")
+	f.Pop()
+	f.Done(&Result{URL: "https://example.com/page", Outcome: OutcomeOK, Links: got})
+
+	if queued := drain(f); !slices.Equal(queued, []string{"https://example.com/same"}) {
+		t.Errorf("queued = %q", queued)
+	}
+}
+
+func TestProcessFreshCache(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, nil)
+	}))
+	defer srv.Close()
+
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawURL := srv.URL + "/page"
+	header := http.Header{"Cache-Control": {"max-age=60"}, "Content-Type": {"text/html"}}
+
+	res, err := cache.Store(rawURL, cacheResponse(rawURL, header, testPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	extractor, err := NewExtractor(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Processor{
+		Client:    srv.Client(),
+		Extractor: extractor,
+		Robots:    NewRobots(srv.Client(), "test"),
+		Hosts:     NewHosts(1, 0),
+		Cache:     cache,
+	}
+
+	result := &Result{URL: rawURL}
+	if err := p.process(context.Background(), result); err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Unchanged || result.StatusCode != http.StatusOK {
+		t.Errorf("result unchanged %t, status %d", result.Unchanged, result.StatusCode)
+	}
+
+	// neither the page nor robots.txt is requested
+	if n := requests.Load(); n != 0 {
+		t.Errorf("requests = %d, want 0", n)
+	}
+}
@@ -0,0 +1,145 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Error classes a request error falls into.
+const (
+	ErrorClassTimeout    = "timeout"
+	ErrorClassDNS        = "dns"
+	ErrorClassConnection = "connection"
+	ErrorClassTLS        = "tls"
+	ErrorClassOther      = "other"
+)
+
+const drainLimit = 64 << 10
+
+var ErrUnknownErrorClass = errors.New("unknown error class")
+
+// RetryPolicy decides whether and when a request is repeated: on the configured
+// statuses and error classes, with exponential backoff and jitter or after the
+// server's Retry-After, both capped by MaxDelay.
+type RetryPolicy struct {
+	Attempts  int
+	Statuses  []int
+	Errors    []string
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func NewRetryPolicy(conf *Config) (*RetryPolicy, error) {
+	classes := []string{ErrorClassTimeout, ErrorClassDNS, ErrorClassConnection, ErrorClassTLS, ErrorClassOther}
+
+	for _, class := range conf.RetryErrors {
+		if !slices.Contains(classes, class) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownErrorClass, class)
+		}
+	}
+
+	return &RetryPolicy{
+		Attempts:  max(conf.RetryCount, 1),
+		Statuses:  conf.RetryStatuses,
+		Errors:    conf.RetryErrors,
+		BaseDelay: conf.RetryBaseDelay,
+		MaxDelay:  conf.RetryMaxDelay,
+	}, nil
+}
+
+// Next reports whether the attempt should be retried and the delay before the next one.
+func (rp *RetryPolicy) Next(attempt int, res *http.Response, err error) (time.Duration, bool) {
+	if attempt >= rp.Attempts {
+		return 0, false
+	}
+
+	if err != nil {
+		return rp.backoff(attempt), slices.Contains(rp.Errors, ErrorClass(err))
+	}
+
+	if !slices.Contains(rp.Statuses, res.StatusCode) {
+		return 0, false
+	}
+
+	delay := rp.backoff(attempt)
+	if after, ok := retryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+		delay = min(max(delay, after), rp.MaxDelay)
+	}
+
+	return delay, true
+}
+
+// backoff doubles the base delay per attempt and picks a random delay in its upper half.
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := rp.BaseDelay << min(attempt-1, 30) //nolint:mnd
+	if delay <= 0 || delay > rp.MaxDelay {
+		delay = rp.MaxDelay
+	}
+
+	half := int64(delay / 2) //nolint:mnd
+	if half <= 0 {
+		return delay
+	}
+
+	return time.Duration(half + rand.Int64N(half+1)) //nolint:gosec
+}
+
+// ErrorClass categorizes a request error.
+func ErrorClass(err error) string {
+	var (
+		dnsErr     *net.DNSError
+		netErr     net.Error
+		certErr    *tls.CertificateVerificationError
+		unknownErr x509.UnknownAuthorityError
+		headerErr  tls.RecordHeaderError
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.As(err, &certErr), errors.As(err, &unknownErr), errors.As(err, &headerErr):
+		return ErrorClassTLS
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, new(*net.OpError)):
+		return ErrorClassConnection
+	}
+
+	return ErrorClassOther
+}
+
+// retryAfter parses a Retry-After value given in seconds or as an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+// discard drains and closes the body of a response that will not be used, so
+// the connection can be reused.
+func discard(res *http.Response) {
+	_, _ = io.CopyN(io.Discard, res.Body, drainLimit)
+	_ = res.Body.Close()
+}
@@ -0,0 +1,124 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newTestRetryPolicy(t *testing.T) *RetryPolicy {
+	t.Helper()
+
+	rp, err := NewRetryPolicy(&Config{
+		RetryCount:     3,
+		RetryStatuses:  []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		RetryErrors:    []string{ErrorClassTimeout, ErrorClassConnection},
+		RetryBaseDelay: 100 * time.Millisecond,
+		RetryMaxDelay:  time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rp
+}
+
+func response(status int, retryAfter string) *http.Response {
+	res := &http.Response{StatusCode: status, Header: make(http.Header)}
+	if retryAfter != "" {
+		res.Header.Set("Retry-After", retryAfter)
+	}
+
+	return res
+}
+
+func TestRetryPolicyNext(t *testing.T) {
+	t.Parallel()
+
+	rp := newTestRetryPolicy(t)
+	connErr := &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}
+
+	tests := []struct {
+		name      string
+		attempt   int
+		res       *http.Response
+		err       error
+		wantRetry bool
+		minDelay  time.Duration
+		maxDelay  time.Duration
+	}{
+		{"retryable status", 1, response(http.StatusServiceUnavailable, ""), nil, true, 50 * time.Millisecond, 100 * time.Millisecond},
+		{"backoff doubles", 2, response(http.StatusServiceUnavailable, ""), nil, true, 100 * time.Millisecond, 200 * time.Millisecond},
+		{"other status", 1, response(http.StatusNotFound, ""), nil, false, 0, 0},
+		{"last attempt", 3, response(http.StatusServiceUnavailable, ""), nil, false, 0, 0},
+		{"retry after", 1, response(http.StatusTooManyRequests, "1"), nil, true, time.Second, time.Second},
+		{"retry after capped", 1, response(http.StatusTooManyRequests, "120"), nil, true, time.Second, time.Second},
+		{"retryable error", 1, nil, connErr, true, 50 * time.Millisecond, 100 * time.Millisecond},
+		{"other error", 1, nil, errors.New("boom"), false, 0, time.Second},
+	}
+
+	for _, tt := range tests {
+		delay, retry := rp.Next(tt.attempt, tt.res, tt.err)
+
+		if retry != tt.wantRetry {
+			t.Errorf("%s: retry = %t", tt.name, retry)
+		}
+
+		if retry && (delay < tt.minDelay || delay > tt.maxDelay) {
+			t.Errorf("%s: delay = %s, want [%s, %s]", tt.name, delay, tt.minDelay, tt.maxDelay)
+		}
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&net.DNSError{Err: "no such host", Name: "example.invalid"}, ErrorClassDNS},
+		{fmt.Errorf("get: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, ErrorClassConnection},
+		{errors.New("boom"), ErrorClassOther},
+	}
+
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.want {
+			t.Errorf("ErrorClass(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+
+	if _, err := NewRetryPolicy(&Config{RetryErrors: []string{"flaky"}}); !errors.Is(err, ErrUnknownErrorClass) {
+		t.Errorf("unknown class: %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"Thu, 01 Jan 2026 12:00:30 GMT", 30 * time.Second, true},
+		{"Thu, 01 Jan 2026 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		if got, ok := retryAfter(tt.value, now); got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %t", tt.value, got, ok)
+		}
+	}
+}
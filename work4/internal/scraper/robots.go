@@ -0,0 +1,242 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const robotsMaxSize = 512 << 10
+
+// RobotsRules are the rules of the robots.txt group matching our user agent.
+type RobotsRules struct {
+	rules       []robotsRule
+	CrawlDelay  time.Duration
+	disallowAll bool
+}
+
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+type robotsGroup struct {
+	agents []string
+	RobotsRules
+}
+
+// Robots fetches and caches robots.txt per host. Transient failures, an
+// unreachable host, a 5xx status or a cancelled fetch, are not cached and
+// robots.txt is fetched again for the next URL of the host.
+type Robots struct {
+	client    *http.Client
+	userAgent string
+
+	mu    sync.Mutex
+	hosts map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	mu    sync.Mutex
+	rules *RobotsRules
+}
+
+func NewRobots(client *http.Client, userAgent string) *Robots {
+	return &Robots{client: client, userAgent: userAgent, hosts: make(map[string]*robotsEntry)}
+}
+
+// Rules returns the rules for the host of the URL, fetching robots.txt on first use.
+func (r *Robots) Rules(ctx context.Context, u *url.URL) *RobotsRules {
+	origin := u.Scheme + "://" + u.Host
+
+	r.mu.Lock()
+	entry, ok := r.hosts[origin]
+	if !ok {
+		entry = &robotsEntry{}
+		r.hosts[origin] = entry
+	}
+	r.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.rules != nil {
+		return entry.rules
+	}
+
+	rules, transient := r.fetch(ctx, origin)
+	if !transient {
+		entry.rules = rules
+	}
+
+	return rules
+}
+
+// fetch follows RFC 9309: a missing robots.txt allows everything, an unreachable
+// one disallows everything and is reported as transient.
+func (r *Robots) fetch(ctx context.Context, origin string) (*RobotsRules, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return &RobotsRules{}, false
+	}
+
+	req.Header.Set("User-Agent", r.userAgent)
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return &RobotsRules{disallowAll: true}, true
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 500: //nolint:mnd
+		return &RobotsRules{disallowAll: true}, true
+	case res.StatusCode >= 400: //nolint:mnd
+		return &RobotsRules{}, false
+	}
+
+	return parseRobots(io.LimitReader(res.Body, robotsMaxSize), r.userAgent), false
+}
+
+// parseRobots picks the group whose user-agent equals our product token,
+// ignoring case, falling back to the "*" group.
+func parseRobots(r io.Reader, userAgent string) *RobotsRules {
+	product := strings.ToLower(strings.SplitN(userAgent, "/", 2)[0]) //nolint:mnd
+
+	var (
+		groups  []*robotsGroup
+		current *robotsGroup
+		inRules bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+
+			inRules = true
+
+			if value != "" {
+				current.rules = append(current.rules, robotsRule{pattern: value, allow: key == "allow"})
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+
+			inRules = true
+
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.CrawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	var best *robotsGroup
+
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			if agent == product {
+				return &group.RobotsRules
+			}
+
+			if agent == "*" && best == nil {
+				best = group
+			}
+		}
+	}
+
+	if best == nil {
+		return &RobotsRules{}
+	}
+
+	return &best.RobotsRules
+}
+
+// Allowed applies the longest matching rule; on a tie allow wins.
+func (rr *RobotsRules) Allowed(u *url.URL) bool {
+	if rr.disallowAll {
+		return false
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	allowed, matched := true, -1
+
+	for _, rule := range rr.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+
+		if len(rule.pattern) > matched || (len(rule.pattern) == matched && rule.allow) {
+			allowed, matched = rule.allow, len(rule.pattern)
+		}
+	}
+
+	return allowed
+}
+
+// robotsMatch matches a path against a robots.txt pattern with "*" wildcards and a "$" end anchor.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	rest := path[len(parts[0]):]
+
+	for _, part := range parts[1:] {
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+
+		rest = rest[idx+len(part):]
+	}
+
+	if !anchored {
+		return true
+	}
+
+	if len(parts) > 1 {
+		return strings.HasSuffix(path, parts[len(parts)-1])
+	}
+
+	return rest == ""
+}
@@ -0,0 +1,145 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testRobots = `
+User-agent: *
+Disallow: /private
+Crawl-delay: 2
+
+User-agent: scraper
+User-agent: work4-scraper-beta
+Disallow: /
+
+User-agent: Work4-Scraper
+Disallow: /admin
+Allow: /admin/public
+Disallow: /*.pdf$
+Crawl-delay: 0.5
+`
+
+func allowed(t *testing.T, rules *RobotsRules, rawURL string) bool {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rules.Allowed(u)
+}
+
+func TestParseRobotsGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		userAgent string
+		delay     time.Duration
+		path      string
+		want      bool
+	}{
+		// the product token matches exactly, ignoring case
+		{"work4-scraper/1.0", 500 * time.Millisecond, "/private", true},
+		{"work4-scraper/1.0", 500 * time.Millisecond, "/admin", false},
+		// a token that is only part of the product name does not match
+		{"work4-scraper-beta2/1.0", 2 * time.Second, "/private", false},
+		{"work4-scraper-beta2/1.0", 2 * time.Second, "/admin", true},
+		{"other/1.0", 2 * time.Second, "/", true},
+	}
+
+	for _, tt := range tests {
+		rules := parseRobots(strings.NewReader(testRobots), tt.userAgent)
+
+		if rules.CrawlDelay != tt.delay {
+			t.Errorf("%s: crawl delay = %s, want %s", tt.userAgent, rules.CrawlDelay, tt.delay)
+		}
+
+		if got := allowed(t, rules, "https://example.com"+tt.path); got != tt.want {
+			t.Errorf("%s %s: allowed = %t, want %t", tt.userAgent, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRobotsAllowed(t *testing.T) {
+	t.Parallel()
+
+	rules := parseRobots(strings.NewReader(testRobots), "work4-scraper/1.0")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"", true},
+		{"/admin/users", false},
+		{"/admin/public/logo.png", true},
+		{"/docs/report.pdf", false},
+		{"/docs/report.pdf?download=1", true},
+	}
+
+	for _, tt := range tests {
+		if got := allowed(t, rules, "https://example.com"+tt.path); got != tt.want {
+			t.Errorf("%q: allowed = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRobotsCache(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests atomic.Int32
+		status   atomic.Int32
+	)
+
+	status.Store(http.StatusServiceUnavailable)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer srv.Close()
+
+	robots := NewRobots(srv.Client(), "work4-scraper/1.0")
+	u, _ := url.Parse(srv.URL + "/page")
+
+	// an unavailable robots.txt disallows everything until it is fetched again
+	if robots.Rules(context.Background(), u).Allowed(u) {
+		t.Fatal("allowed while robots.txt is unavailable")
+	}
+
+	status.Store(http.StatusOK)
+
+	if !robots.Rules(context.Background(), u).Allowed(u) {
+		t.Fatal("disallowed after robots.txt recovered")
+	}
+
+	robots.Rules(context.Background(), u)
+
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("robots.txt requests = %d, want 2", n)
+	}
+
+	// a cancelled fetch is not cached either
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	other, _ := url.Parse(strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/page")
+
+	if robots.Rules(ctx, other).Allowed(other) {
+		t.Fatal("allowed after a cancelled fetch")
+	}
+
+	if !robots.Rules(context.Background(), other).Allowed(other) {
+		t.Fatal("cancelled fetch cached")
+	}
+}
@@ -1,8 +1,67 @@
 package scraper
 
-import "sync"
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
 
-func Run(conf *Config, input <-chan string) <-chan *Result {
+// Run fetches the input URLs with ThreadCount workers. In crawl mode the links
+// found on fetched pages are fed back through the frontier; the output is closed
+// once the input is closed and the frontier is exhausted. Failed URLs are
+// delivered as results with Err and Outcome set.
+//
+// Cancelling ctx stops the intake: no new URLs are read or dispatched, while the
+// requests in flight are completed and their results delivered. The Deadline
+// from the config bounds the whole crawl including the requests in flight.
+//
+// A non-nil state makes the crawl resumable: the frontier is restored from it
+// and every change is recorded.
+func Run(ctx context.Context, conf *Config, extractor *Extractor, state State, input <-chan string) (<-chan *Result, error) {
+	frontier, err := NewFrontier(conf, state)
+	if err != nil {
+		return nil, err
+	}
+
+	retry, err := NewRetryPolicy(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := NewClient(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var robots *Robots
+	if conf.Robots {
+		robots = NewRobots(client, conf.UserAgent)
+	}
+
+	hosts := NewHosts(conf.HostConcurrency, conf.HostDelay)
+
+	var cache *Cache
+	if conf.CacheDir != "" {
+		if cache, err = NewCache(conf.CacheDir); err != nil {
+			return nil, err
+		}
+	}
+
+	work := context.WithoutCancel(ctx)
+	cancel := func() {}
+
+	if conf.Deadline > 0 {
+		var cancelIntake, cancelWork context.CancelFunc
+
+		deadline := time.Now().Add(conf.Deadline)
+		ctx, cancelIntake = context.WithDeadline(ctx, deadline)
+		work, cancelWork = context.WithDeadline(work, deadline)
+		cancel = func() { cancelIntake(); cancelWork() }
+	}
+
+	tasks := make(chan *Task)
+	results := make(chan *Result)
 	output := make(chan *Result)
 
 	var wg sync.WaitGroup
@@ -11,15 +70,64 @@ func Run(conf *Config, input <-chan string) <-chan *Result {
 		wg.Add(1)
 
 		go func() {
-			NewProcessor(conf).Do(input, output)
+			NewProcessor(conf, client, extractor, retry, robots, hosts, cache).Do(work, tasks, results)
 			wg.Done()
 		}()
 	}
 
 	go func() {
+		defer cancel()
+
+		dispatch(ctx, frontier, input, tasks, results, output)
+		close(tasks)
 		wg.Wait()
 		close(output)
 	}()
 
-	return output
+	return output, nil
+}
+
+// dispatch hands frontier tasks to the workers and collects their results
+// until there is nothing queued, in flight or left to read from the input.
+func dispatch(
+	ctx context.Context, frontier *Frontier, input <-chan string, tasks chan<- *Task, results <-chan *Result, output chan<- *Result,
+) {
+	inFlight := 0
+	done := ctx.Done()
+
+	for input != nil || frontier.Len() > 0 || inFlight > 0 {
+		var (
+			send chan<- *Task
+			next *Task
+		)
+
+		if frontier.Len() > 0 {
+			send, next = tasks, frontier.Next()
+		}
+
+		select {
+		case <-done:
+			slog.Warn("crawl stopped, draining", slog.String("reason", context.Cause(ctx).Error()),
+				slog.Int("in_flight", inFlight), slog.Int("queued", frontier.Len()))
+
+			input, done = nil, nil
+			frontier.Stop()
+		case url, ok := <-input:
+			if !ok {
+				input = nil
+
+				continue
+			}
+
+			frontier.Seed(url)
+		case send <- next:
+			frontier.Pop()
+			inFlight++
+		case res := <-results:
+			inFlight--
+
+			frontier.Done(res)
+			output <- res
+		}
+	}
 }
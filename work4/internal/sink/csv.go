@@ -0,0 +1,83 @@
+package sink
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/mch735/education/work4/internal/scraper"
+)
+
+// CSV writes results as csv rows under a header of the columns; the header is
+// not repeated when appending to a non-empty file, but it must match.
+type CSV struct {
+	file    *os.File
+	writer  *csv.Writer
+	columns []string
+}
+
+func NewCSV(path string, columns []string, appending bool) (*CSV, error) {
+	file, empty, err := open(path, appending)
+	if err != nil {
+		return nil, fmt.Errorf("csv sink: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+
+	if !empty {
+		if err := checkHeader(path, columns); err != nil {
+			_ = file.Close()
+
+			return nil, fmt.Errorf("csv sink: %w", err)
+		}
+
+		return &CSV{file: file, writer: writer, columns: columns}, nil
+	}
+
+	if err := writer.Write(columns); err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("csv sink: %w", err)
+	}
+
+	return &CSV{file: file, writer: writer, columns: columns}, nil
+}
+
+func (c *CSV) Write(res *scraper.Result) error {
+	if err := c.writer.Write(res.Fields(c.columns)); err != nil {
+		return fmt.Errorf("csv sink: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CSV) Close() error {
+	c.writer.Flush()
+
+	return errors.Join(c.writer.Error(), c.file.Close())
+}
+
+// checkHeader compares the header of an existing csv file with the expected columns.
+func checkHeader(path string, columns []string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if !slices.Equal(header, columns) {
+		return fmt.Errorf("%w: csv file has header %q, want %q", ErrColumnsChanged, header, columns)
+	}
+
+	return nil
+}
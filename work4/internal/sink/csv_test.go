@@ -0,0 +1,57 @@
+package sink
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mch735/education/work4/internal/scraper"
+)
+
+func writeCSV(t *testing.T, path string, columns []string, appending bool, results ...*scraper.Result) {
+	t.Helper()
+
+	c, err := NewCSV(path, columns, appending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, res := range results {
+		if err := c.Write(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCSVRuns(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	columns := []string{"url", "status_code"}
+	res := &scraper.Result{URL: "https://example.com/", StatusCode: 200}
+
+	writeCSV(t, path, columns, false, res)
+	writeCSV(t, path, columns, true, res)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "url,status_code\nhttps://example.com/,200\nhttps://example.com/,200\n"
+	if string(data) != want {
+		t.Fatalf("file after resume = %q, want %q", data, want)
+	}
+
+	if _, err := NewCSV(path, []string{"url", "title"}, true); !errors.Is(err, ErrColumnsChanged) {
+		t.Fatalf("resume with other columns: %v", err)
+	}
+
+	// a new run starts over, even with other columns
+	writeCSV(t, path, []string{"url"}, false, res)
+}
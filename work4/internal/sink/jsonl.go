@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/mch735/education/work4/internal/scraper"
+)
+
+// JSONL writes a json object per result: status_code, attempts and duration_ms
+// as numbers, unchanged as a boolean, the other built-in columns as strings and
+// extracted fields as arrays of values.
+type JSONL struct {
+	closer  io.Closer
+	writer  *bufio.Writer
+	columns []string
+	flush   bool
+}
+
+func NewJSONL(path string, columns []string, appending bool) (*JSONL, error) {
+	file, _, err := open(path, appending)
+	if err != nil {
+		return nil, fmt.Errorf("jsonl sink: %w", err)
+	}
+
+	return &JSONL{closer: file, writer: bufio.NewWriter(file), columns: columns}, nil
+}
+
+// NewStdout writes json lines to the standard output, a line as soon as a result arrives.
+func NewStdout(columns []string) *JSONL {
+	return &JSONL{writer: bufio.NewWriter(os.Stdout), columns: columns, flush: true}
+}
+
+func (j *JSONL) Write(res *scraper.Result) error {
+	record := make(map[string]any, len(j.columns))
+
+	for _, column := range j.columns {
+		record[column] = value(res, column)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("jsonl sink: %w", err)
+	}
+
+	if _, err := j.writer.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("jsonl sink: %w", err)
+	}
+
+	if j.flush {
+		if err := j.writer.Flush(); err != nil {
+			return fmt.Errorf("jsonl sink: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (j *JSONL) Close() error {
+	err := j.writer.Flush()
+
+	if j.closer != nil {
+		err = errors.Join(err, j.closer.Close())
+	}
+
+	return err //nolint:wrapcheck
+}
+
+// value returns the column value with integer columns as numbers and extracted fields as arrays.
+func value(res *scraper.Result, column string) any {
+	switch column {
+	case "status_code":
+		return res.StatusCode
+	case "unchanged":
+		return res.Unchanged
+	case "attempts":
+		return res.Attempts
+	case "duration_ms":
+		return res.Duration.Milliseconds()
+	}
+
+	if slices.Contains(scraper.Columns, column) {
+		return res.Field(column)
+	}
+
+	if values := res.Values[column]; len(values) > 0 {
+		return values
+	}
+
+	return []string{}
+}
@@ -0,0 +1,95 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/mch735/education/work4/internal/scraper"
+)
+
+const (
+	KindCSV    = "csv"
+	KindJSONL  = "jsonl"
+	KindSQLite = "sqlite"
+	KindStdout = "stdout"
+)
+
+// integerColumns are stored as numbers where the sink supports it, booleans as 0 and 1.
+var integerColumns = []string{"status_code", "unchanged", "attempts", "duration_ms"}
+
+var (
+	ErrUnknownKind    = errors.New("unknown sink")
+	ErrUnknownColumn  = errors.New("unknown column")
+	ErrColumnsChanged = errors.New("columns changed since the previous run")
+)
+
+// Sink stores results; Close flushes buffered results.
+type Sink interface {
+	Write(res *scraper.Result) error
+	Close() error
+}
+
+// New opens the sink of the kind at the path, data.<kind> if empty. Columns
+// select and order the stored values out of the available ones, all by default.
+// Appending keeps the results already in the file, for resumed crawls.
+func New(kind, path string, columns, available []string, appending bool) (Sink, error) {
+	if len(columns) == 0 {
+		columns = available
+	}
+
+	for _, column := range columns {
+		if !slices.Contains(available, column) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, column)
+		}
+	}
+
+	if path == "" {
+		path = defaultPath(kind)
+	}
+
+	switch kind {
+	case KindCSV:
+		return NewCSV(path, columns, appending)
+	case KindJSONL:
+		return NewJSONL(path, columns, appending)
+	case KindSQLite:
+		return NewSQLite(path, columns, appending)
+	case KindStdout:
+		return NewStdout(columns), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+}
+
+// open creates or truncates the file, or opens it for appending; it reports
+// whether the file is empty.
+func open(path string, appending bool) (*os.File, bool, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appending {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(path, flags, 0o644) //nolint:mnd
+	if err != nil {
+		return nil, false, err //nolint:wrapcheck
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return nil, false, err //nolint:wrapcheck
+	}
+
+	return file, info.Size() == 0, nil
+}
+
+func defaultPath(kind string) string {
+	if kind == KindSQLite {
+		return "data.db"
+	}
+
+	return "data." + kind
+}
@@ -0,0 +1,127 @@
+package sink
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	_ "modernc.org/sqlite" // database/sql driver
+
+	"github.com/mch735/education/work4/internal/scraper"
+)
+
+// SQLite inserts results into the results table of a database file, a column
+// per result column. The table is recreated unless appending; appending needs
+// the table to have the same columns.
+type SQLite struct {
+	db      *sql.DB
+	insert  *sql.Stmt
+	columns []string
+}
+
+func NewSQLite(path string, columns []string, appending bool) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite sink: %w", err)
+	}
+
+	names := make([]string, 0, len(columns))
+	defs := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		name := `"` + strings.ReplaceAll(column, `"`, `""`) + `"`
+		names = append(names, name)
+
+		if slices.Contains(integerColumns, column) {
+			defs = append(defs, name+" INTEGER")
+		} else {
+			defs = append(defs, name+" TEXT")
+		}
+	}
+
+	stmts := []string{"PRAGMA journal_mode=WAL", "DROP TABLE IF EXISTS results"}
+	if appending {
+		stmts = stmts[:1]
+	}
+
+	stmts = append(stmts, "CREATE TABLE IF NOT EXISTS results ("+strings.Join(defs, ", ")+")")
+
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+
+			return nil, fmt.Errorf("sqlite sink: %w", err)
+		}
+	}
+
+	if err := checkColumns(db, columns); err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("sqlite sink: %w", err)
+	}
+
+	insert, err := db.Prepare("INSERT INTO results (" + strings.Join(names, ", ") + ") VALUES (" + //nolint:gosec
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")")
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("sqlite sink: %w", err)
+	}
+
+	return &SQLite{db: db, insert: insert, columns: columns}, nil
+}
+
+func (s *SQLite) Write(res *scraper.Result) error {
+	args := make([]any, 0, len(s.columns))
+
+	for _, column := range s.columns {
+		if slices.Contains(integerColumns, column) {
+			args = append(args, value(res, column))
+		} else {
+			args = append(args, res.Field(column))
+		}
+	}
+
+	if _, err := s.insert.Exec(args...); err != nil {
+		return fmt.Errorf("sqlite sink: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) Close() error {
+	_ = s.insert.Close()
+
+	return s.db.Close() //nolint:wrapcheck
+}
+
+// checkColumns compares the columns of an existing results table with the expected ones.
+func checkColumns(db *sql.DB, columns []string) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('results') ORDER BY cid")
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer rows.Close()
+
+	var existing []string
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		existing = append(existing, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if !slices.Equal(existing, columns) {
+		return fmt.Errorf("%w: results table has columns %q, want %q", ErrColumnsChanged, existing, columns)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package sink
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mch735/education/work4/internal/scraper"
+)
+
+func writeSQLite(t *testing.T, path string, columns []string, appending bool, results ...*scraper.Result) {
+	t.Helper()
+
+	s, err := NewSQLite(path, columns, appending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, res := range results {
+		if err := s.Write(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countRows(t *testing.T, path string) int {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM results").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestSQLiteRuns(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "data.sqlite")
+	columns := []string{"url", "status_code", "title"}
+	res := &scraper.Result{URL: "https://example.com/", StatusCode: 200, Values: map[string][]string{"title": {"Example"}}}
+
+	writeSQLite(t, path, columns, false, res, res)
+	writeSQLite(t, path, columns, true, res)
+
+	if n := countRows(t, path); n != 3 {
+		t.Fatalf("rows after resume = %d, want 3", n)
+	}
+
+	// a new run starts over, even with other columns
+	writeSQLite(t, path, []string{"url", "status_code"}, false, res)
+
+	if n := countRows(t, path); n != 1 {
+		t.Fatalf("rows after new run = %d, want 1", n)
+	}
+
+	if _, err := NewSQLite(path, columns, true); !errors.Is(err, ErrColumnsChanged) {
+		t.Fatalf("resume with other columns: %v", err)
+	}
+}
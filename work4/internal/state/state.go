@@ -0,0 +1,131 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // database/sql driver
+
+	"github.com/mch735/education/work4/internal/scraper"
+)
+
+const (
+	statusQueued   = "queued"
+	statusInFlight = "in_flight"
+	statusDone     = "done"
+)
+
+var ErrUnknownURL = errors.New("unknown url")
+
+const schema = `
+CREATE TABLE IF NOT EXISTS urls (
+	key        TEXT PRIMARY KEY,
+	url        TEXT NOT NULL,
+	depth      INTEGER NOT NULL,
+	status     TEXT NOT NULL,
+	outcome    TEXT NOT NULL DEFAULT '',
+	fetched_at INTEGER NOT NULL DEFAULT 0
+)`
+
+// Store keeps the crawl state in a SQLite file: the visited set, the frontier
+// queue and the outcome of every URL. Done URLs older than the TTL are fetched
+// again on resume; a zero TTL never refetches.
+type Store struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+var _ scraper.State = (*Store)(nil)
+
+func Open(path string, ttl time.Duration) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("state: %w", err)
+	}
+
+	// the frontier is driven by a single goroutine
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{"PRAGMA journal_mode=WAL", "PRAGMA synchronous=NORMAL", schema} {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+
+			return nil, fmt.Errorf("state: %w", err)
+		}
+	}
+
+	return &Store{db: db, ttl: ttl}, nil
+}
+
+func (s *Store) Load() ([]string, []*scraper.Task, error) {
+	rows, err := s.db.Query("SELECT key, url, depth, status, fetched_at FROM urls ORDER BY rowid")
+	if err != nil {
+		return nil, nil, fmt.Errorf("state: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		done    []string
+		pending []*scraper.Task
+	)
+
+	for rows.Next() {
+		var (
+			key, url, status string
+			depth            int
+			fetchedAt        int64
+		)
+
+		if err := rows.Scan(&key, &url, &depth, &status, &fetchedAt); err != nil {
+			return nil, nil, fmt.Errorf("state: %w", err)
+		}
+
+		if status == statusDone && (s.ttl == 0 || time.Since(time.Unix(fetchedAt, 0)) < s.ttl) {
+			done = append(done, key)
+
+			continue
+		}
+
+		pending = append(pending, &scraper.Task{URL: url, Depth: depth})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("state: %w", err)
+	}
+
+	return done, pending, nil
+}
+
+// Queue records a new URL; a URL known from a previous run is marked queued again.
+func (s *Store) Queue(key string, task *scraper.Task) error {
+	return s.exec(`INSERT INTO urls (key, url, depth, status) VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET status = excluded.status`, key, task.URL, task.Depth, statusQueued)
+}
+
+func (s *Store) Start(key string) error {
+	return s.exec("UPDATE urls SET status = ? WHERE key = ?", statusInFlight, key)
+}
+
+func (s *Store) Finish(key string, outcome string) error {
+	return s.exec("UPDATE urls SET status = ?, outcome = ?, fetched_at = ? WHERE key = ?",
+		statusDone, outcome, time.Now().Unix(), key)
+}
+
+func (s *Store) Close() error {
+	return s.db.Close() //nolint:wrapcheck
+}
+
+func (s *Store) exec(query string, args ...any) error {
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("state: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("state: %w", ErrUnknownURL)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package state
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mch735/education/work4/internal/scraper"
+)
+
+func open(t *testing.T, path string, ttl time.Duration) *Store {
+	t.Helper()
+
+	s, err := Open(path, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func urls(tasks []*scraper.Task) []string {
+	res := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		res = append(res, task.URL)
+	}
+
+	return res
+}
+
+func TestStoreResume(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.db")
+	s := open(t, path, 0)
+
+	for _, key := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+		if err := s.Queue(key, &scraper.Task{URL: key, Depth: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Start("https://example.com/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Finish("https://example.com/a", scraper.OutcomeOK); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Start("https://example.com/b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Finish("https://example.com/unknown", scraper.OutcomeOK); !errors.Is(err, ErrUnknownURL) {
+		t.Fatalf("finish unknown url: %v", err)
+	}
+
+	_ = s.Close()
+
+	done, pending, err := open(t, path, 0).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(done, []string{"https://example.com/a"}) {
+		t.Errorf("done = %q", done)
+	}
+
+	// in-flight URLs are pending again
+	if got := urls(pending); !slices.Equal(got, []string{"https://example.com/b", "https://example.com/c"}) {
+		t.Errorf("pending = %q", got)
+	}
+
+	if pending[0].Depth != 1 {
+		t.Errorf("depth = %d, want 1", pending[0].Depth)
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.db")
+	s := open(t, path, 0)
+
+	if err := s.Queue("https://example.com/", &scraper.Task{URL: "https://example.com/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Finish("https://example.com/", scraper.OutcomeOK); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = s.Close()
+
+	done, pending, err := open(t, path, time.Nanosecond).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(done) != 0 || !slices.Equal(urls(pending), []string{"https://example.com/"}) {
+		t.Errorf("expired url: done = %q, pending = %q", done, urls(pending))
+	}
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
+	"flag"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/mch735/education/work4/internal/scraper"
+	"github.com/mch735/education/work4/internal/sink"
+	statestore "github.com/mch735/education/work4/internal/state"
 )
 
 func main() {
@@ -17,38 +25,113 @@ func main() {
 		panic(err)
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	flag.StringVar(&conf.Output, "output", conf.Output, "output sink: csv, jsonl, sqlite, stdout")
+	flag.StringVar(&conf.OutputPath, "output-path", conf.OutputPath, "output file, data.<sink> by default")
+	flag.StringVar(&conf.Failures, "failures", conf.Failures, "separate file for failed urls, written to the output by default")
+	flag.StringVar(&conf.Resume, "resume", conf.Resume, "crawl state file to resume from and record to")
+	flag.Func("columns", "comma separated output columns, all by default", func(value string) error {
+		conf.Columns = strings.Split(value, ",")
+
+		return nil
+	})
+	flag.Parse()
+
+	// stdout is taken by the results
+	var logOutput io.Writer = os.Stdout
+	if conf.Output == sink.KindStdout {
+		logOutput = os.Stderr
+	}
+
+	logger := slog.New(slog.NewJSONHandler(logOutput, nil))
 	slog.SetDefault(logger)
 
+	// the first signal stops the intake and lets the requests in flight finish,
+	// a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	context.AfterFunc(ctx, stop)
+
 	input := make(chan string)
 	go func() {
-		for _, arg := range os.Args[1:] {
-			input <- arg
-		}
-
 		defer close(input)
+
+		for _, arg := range flag.Args() {
+			select {
+			case input <- arg:
+			case <-ctx.Done():
+				return
+			}
+		}
 	}()
 
-	file, err := os.Create("data.csv")
+	extractor, err := scraper.NewExtractor(&conf)
 	if err != nil {
 		panic(err)
 	}
-	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	var state scraper.State
 
-	err = writer.Write([]string{"date", "url", "status_code", "title", "description"})
+	if conf.Resume != "" {
+		store, err := statestore.Open(conf.Resume, conf.StateTTL)
+		if err != nil {
+			panic(err)
+		}
+		defer store.Close()
+
+		state = store
+	}
+
+	resuming := state != nil
+
+	output, err := sink.New(conf.Output, conf.OutputPath, conf.Columns, slices.Concat(scraper.Columns, extractor.Names()), resuming)
 	if err != nil {
 		panic(err)
 	}
 
-	output := scraper.Run(&conf, input)
+	failures := output
+	if conf.Failures != "" {
+		kind := conf.Output
+		if kind == sink.KindStdout {
+			kind = sink.KindJSONL
+		}
 
-	for data := range output {
-		err := writer.Write(data.Fields())
+		failures, err = sink.New(kind, conf.Failures, nil, scraper.Columns, resuming)
 		if err != nil {
 			panic(err)
 		}
 	}
+
+	results, err := scraper.Run(ctx, &conf, extractor, state, input)
+	if err != nil {
+		panic(err)
+	}
+
+	summary := scraper.NewSummary()
+
+	for data := range results {
+		summary.Add(data)
+
+		target := output
+		if data.Err != nil {
+			target = failures
+		}
+
+		err := target.Write(data)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if failures != output {
+		if err := failures.Close(); err != nil {
+			panic(err)
+		}
+	}
+
+	if err := output.Close(); err != nil {
+		panic(err)
+	}
+
+	summary.Log(logger)
 }